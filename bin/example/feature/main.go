@@ -52,10 +52,7 @@ func startUserInstance(wg *sync.WaitGroup, client *consul.Client, logger *log.Lo
 	mux := http.NewServeMux()
 
 	// 健康检查端点
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]string{"status": "healthy"})
-	})
+	consul.MountHealthEndpoints(mux, nil)
 
 	// 用户信息端点
 	mux.HandleFunc("/users/info", func(w http.ResponseWriter, r *http.Request) {
@@ -74,6 +74,7 @@ type OrderService struct {
 	port           int
 	userInvoker    *consul.ServiceInvoker
 	paymentInvoker *consul.ServiceInvoker
+	orderSeq       *consul.Sequence
 }
 
 // 启动用户服务
@@ -100,58 +101,56 @@ func startUserService(wg *sync.WaitGroup, client *consul.Client, logger *log.Log
 		"oauth_login":        true,
 	}
 
-	// 写入初始配置
-	configBytes, _ := json.Marshal(initialConfig)
-	if err := client.Put("config/user-service", configBytes); err != nil {
-		userService.logger.Printf("Failed to write initial config: %v", err)
-		return
-	}
-
+	// 写入缺失的初始配置、监听配置并注册服务，任一步骤失败时回滚已完成的步骤
 	serviceID := "user-service-1"
-	// 注册服务
-	err := client.RegisterService(&consul.ServiceConfig{
-		Name:    "user-service",
-		ID:      serviceID,
-		Address: userService.address,
-		Port:    userService.port,
-		Tags:    []string{"api", "v1", "user"},
-		Meta: map[string]string{
-			"version": "1.0.0",
-			"env":     "dev",
-		},
-		Checks: []*consul.CheckConfig{
-			{
-				HTTP:            fmt.Sprintf("http://%s:%d/health", userService.address, userService.port),
-				Interval:        time.Second * 10,
-				Timeout:         time.Second * 5,
-				DeregisterAfter: time.Minute,
+	configBytes, _ := json.Marshal(initialConfig)
+	startCtx, cancelStart := context.WithTimeout(ctx, 30*time.Second)
+	bundle, err := consul.Bootstrap(startCtx, consul.BundleSpec{
+		Client:  client,
+		Configs: map[string][]byte{"config/user-service": configBytes},
+		Watches: []consul.WatchSpec{{
+			Key:    "config/user-service",
+			Target: userService.config,
+			Options: &consul.WatchOptions{
+				WaitTime:  time.Second * 10,
+				RetryTime: time.Second,
 			},
-		},
+		}},
+		Services: []*consul.ServiceConfig{{
+			Name:    "user-service",
+			ID:      serviceID,
+			Address: userService.address,
+			Port:    userService.port,
+			Tags:    []string{"api", "v1", "user"},
+			Meta: map[string]string{
+				"version": "1.0.0",
+				"env":     "dev",
+			},
+			Checks: []*consul.CheckConfig{
+				{
+					HTTP:            fmt.Sprintf("http://%s:%d/health", userService.address, userService.port),
+					Interval:        time.Second * 10,
+					Timeout:         time.Second * 5,
+					DeregisterAfter: time.Minute,
+				},
+			},
+		}},
 	})
+	cancelStart()
 	if err != nil {
-		userService.logger.Printf("Failed to register service: %v", err)
+		userService.logger.Printf("Failed to bootstrap: %v", err)
 		return
 	}
 
-	// 确保服务退出时注销服务
+	// 确保服务退出时注销服务并停止监听
 	defer func() {
-		if err := client.DeregisterService(serviceID); err != nil {
+		if err := bundle.Teardown(); err != nil {
 			userService.logger.Printf("Error deregistering service: %v", err)
 		} else {
 			userService.logger.Printf("Service deregistered successfully: %s", serviceID)
 		}
 	}()
 
-	// 监听配置变更
-	err = client.WatchConfig("config/user-service", userService.config, &consul.WatchOptions{
-		WaitTime:  time.Second * 10,
-		RetryTime: time.Second,
-	})
-	if err != nil {
-		userService.logger.Printf("Failed to watch config: %v", err)
-		return
-	}
-
 	// HTTP 处理函数
 	mux := http.NewServeMux()
 	mux.HandleFunc("/users/verify", func(w http.ResponseWriter, r *http.Request) {
@@ -172,10 +171,7 @@ func startUserService(wg *sync.WaitGroup, client *consul.Client, logger *log.Log
 		})
 	})
 
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]string{"status": "healthy"})
-	})
+	consul.MountHealthEndpoints(mux, nil)
 
 	// HTTP 服务器
 	server := &http.Server{
@@ -236,66 +232,65 @@ func startPaymentService(wg *sync.WaitGroup, client *consul.Client, logger *log.
 		DefaultProvider: "stripe",
 	}
 
-	// 写入初始配置
-	configBytes, _ := json.Marshal(initialConfig)
-	if err := client.Put("config/payment-service", configBytes); err != nil {
-		paymentService.logger.Printf("Failed to write initial config: %v", err)
-		return
-	}
-
+	// 写入缺失的初始配置、监听配置、注册服务并创建调用器，任一步骤失败时回滚已完成的步骤
 	serviceID := "payment-service-1"
-	// 注册服务
-	err := client.RegisterService(&consul.ServiceConfig{
-		Name:    "payment-service",
-		ID:      serviceID,
-		Address: paymentService.address,
-		Port:    paymentService.port,
-		Tags:    []string{"api", "v1", "payment"},
-		Meta: map[string]string{
-			"version": "1.0.0",
-			"env":     "dev",
-		},
-		Checks: []*consul.CheckConfig{
-			{
-				HTTP:            fmt.Sprintf("http://%s:%d/health", paymentService.address, paymentService.port),
-				Interval:        time.Second * 10,
-				Timeout:         time.Second * 5,
-				DeregisterAfter: time.Minute,
+	configBytes, _ := json.Marshal(initialConfig)
+	startCtx, cancelStart := context.WithTimeout(ctx, 30*time.Second)
+	bundle, err := consul.Bootstrap(startCtx, consul.BundleSpec{
+		Client:  client,
+		Configs: map[string][]byte{"config/payment-service": configBytes},
+		Watches: []consul.WatchSpec{{
+			Key:    "config/payment-service",
+			Target: paymentService.config,
+			Options: &consul.WatchOptions{
+				WaitTime:  time.Second * 10,
+				RetryTime: time.Second,
+			},
+		}},
+		Services: []*consul.ServiceConfig{{
+			Name:    "payment-service",
+			ID:      serviceID,
+			Address: paymentService.address,
+			Port:    paymentService.port,
+			Tags:    []string{"api", "v1", "payment"},
+			Meta: map[string]string{
+				"version": "1.0.0",
+				"env":     "dev",
+			},
+			Checks: []*consul.CheckConfig{
+				{
+					HTTP:            fmt.Sprintf("http://%s:%d/health", paymentService.address, paymentService.port),
+					Interval:        time.Second * 10,
+					Timeout:         time.Second * 5,
+					DeregisterAfter: time.Minute,
+				},
+			},
+		}},
+		Invokers: map[string][]consul.InvokerOption{
+			"user-service": {
+				consul.WithStrategy(consul.RoundRobin),
+				consul.WithInvokeTimeout(time.Second * 5),
+				consul.WithRetry(3, time.Second),
+				consul.WithTags([]string{"api", "v1"}),
 			},
 		},
 	})
+	cancelStart()
 	if err != nil {
-		paymentService.logger.Printf("Failed to register service: %v", err)
+		paymentService.logger.Printf("Failed to bootstrap: %v", err)
 		return
 	}
+	paymentService.userInvoker = bundle.Invoker("user-service")
 
-	// 确保服务退出时注销服务
+	// 确保服务退出时注销服务并停止监听
 	defer func() {
-		if err := client.DeregisterService(serviceID); err != nil {
+		if err := bundle.Teardown(); err != nil {
 			paymentService.logger.Printf("Error deregistering service: %v", err)
 		} else {
 			paymentService.logger.Printf("Service deregistered successfully: %s", serviceID)
 		}
 	}()
 
-	// 监听配置变更
-	err = client.WatchConfig("config/payment-service", paymentService.config, &consul.WatchOptions{
-		WaitTime:  time.Second * 10,
-		RetryTime: time.Second,
-	})
-	if err != nil {
-		paymentService.logger.Printf("Failed to watch config: %v", err)
-		return
-	}
-
-	// 创建用户服务调用器
-	paymentService.userInvoker = client.NewServiceInvoker("user-service",
-		consul.WithStrategy(consul.RoundRobin),
-		consul.WithInvokeTimeout(time.Second*5),
-		consul.WithRetry(3, time.Second),
-		consul.WithTags([]string{"api", "v1"}),
-	)
-
 	// HTTP 处理函数
 	mux := http.NewServeMux()
 	mux.HandleFunc("/payments/process", func(w http.ResponseWriter, r *http.Request) {
@@ -326,10 +321,7 @@ func startPaymentService(wg *sync.WaitGroup, client *consul.Client, logger *log.
 		})
 	})
 
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]string{"status": "healthy"})
-	})
+	consul.MountHealthEndpoints(mux, nil)
 
 	// HTTP 服务器
 	server := &http.Server{
@@ -368,6 +360,7 @@ func startOrderService(wg *sync.WaitGroup, client *consul.Client, logger *log.Lo
 		address: "192.168.40.30",
 		port:    8083,
 	}
+	orderService.orderSeq = client.Sequence("sequence/order-id", 100)
 
 	// 初始化订单服务配置
 	initialConfig := OrderConfig{
@@ -382,74 +375,72 @@ func startOrderService(wg *sync.WaitGroup, client *consul.Client, logger *log.Lo
 		},
 	}
 
-	// 写入初始配置
-	configBytes, _ := json.Marshal(initialConfig)
-	if err := client.Put("config/order-service", configBytes); err != nil {
-		orderService.logger.Printf("Failed to write initial config: %v", err)
-		return
-	}
-
+	// 写入缺失的初始配置、监听配置、注册服务并创建调用器，任一步骤失败时回滚已完成的步骤
 	serviceID := "order-service-1"
-	// 注册服务
-	err := client.RegisterService(&consul.ServiceConfig{
-		Name:    "order-service",
-		ID:      serviceID,
-		Address: orderService.address,
-		Port:    orderService.port,
-		Tags:    []string{"api", "v1", "order"},
-		Meta: map[string]string{
-			"version": "1.0.0",
-			"env":     "dev",
-		},
-		Checks: []*consul.CheckConfig{
-			{
-				HTTP:            fmt.Sprintf("http://%s:%d/health", orderService.address, orderService.port),
-				Interval:        time.Second * 10,
-				Timeout:         time.Second * 5,
-				DeregisterAfter: time.Minute,
+	configBytes, _ := json.Marshal(initialConfig)
+	startCtx, cancelStart := context.WithTimeout(ctx, 30*time.Second)
+	bundle, err := consul.Bootstrap(startCtx, consul.BundleSpec{
+		Client:  client,
+		Configs: map[string][]byte{"config/order-service": configBytes},
+		Watches: []consul.WatchSpec{{
+			Key:    "config/order-service",
+			Target: orderService.config,
+			Options: &consul.WatchOptions{
+				WaitTime:  time.Second * 10,
+				RetryTime: time.Second,
+			},
+		}},
+		Services: []*consul.ServiceConfig{{
+			Name:    "order-service",
+			ID:      serviceID,
+			Address: orderService.address,
+			Port:    orderService.port,
+			Tags:    []string{"api", "v1", "order"},
+			Meta: map[string]string{
+				"version": "1.0.0",
+				"env":     "dev",
+			},
+			Checks: []*consul.CheckConfig{
+				{
+					HTTP:            fmt.Sprintf("http://%s:%d/health", orderService.address, orderService.port),
+					Interval:        time.Second * 10,
+					Timeout:         time.Second * 5,
+					DeregisterAfter: time.Minute,
+				},
+			},
+		}},
+		Invokers: map[string][]consul.InvokerOption{
+			"user-service": {
+				consul.WithStrategy(consul.RoundRobin),
+				consul.WithInvokeTimeout(time.Second * 5),
+				consul.WithRetry(3, time.Second),
+				consul.WithTags([]string{"api", "v1"}),
+			},
+			"payment-service": {
+				consul.WithStrategy(consul.RoundRobin),
+				consul.WithInvokeTimeout(time.Second * 5),
+				consul.WithRetry(3, time.Second),
+				consul.WithTags([]string{"api", "v1"}),
 			},
 		},
 	})
+	cancelStart()
 	if err != nil {
-		orderService.logger.Printf("Failed to register service: %v", err)
+		orderService.logger.Printf("Failed to bootstrap: %v", err)
 		return
 	}
+	orderService.userInvoker = bundle.Invoker("user-service")
+	orderService.paymentInvoker = bundle.Invoker("payment-service")
 
-	// 确保服务退出时注销服务
+	// 确保服务退出时注销服务并停止监听
 	defer func() {
-		if err := client.DeregisterService(serviceID); err != nil {
+		if err := bundle.Teardown(); err != nil {
 			orderService.logger.Printf("Error deregistering service: %v", err)
 		} else {
 			orderService.logger.Printf("Service deregistered successfully: %s", serviceID)
 		}
 	}()
 
-	// 监听配置变更
-	err = client.WatchConfig("config/order-service", orderService.config, &consul.WatchOptions{
-		WaitTime:  time.Second * 10,
-		RetryTime: time.Second,
-	})
-	if err != nil {
-		orderService.logger.Printf("Failed to watch config: %v", err)
-		return
-	}
-
-	// 创建用户服务调用器
-	orderService.userInvoker = client.NewServiceInvoker("user-service",
-		consul.WithStrategy(consul.RoundRobin),
-		consul.WithInvokeTimeout(time.Second*5),
-		consul.WithRetry(3, time.Second),
-		consul.WithTags([]string{"api", "v1"}),
-	)
-
-	// 创建支付服务调用器
-	orderService.paymentInvoker = client.NewServiceInvoker("payment-service",
-		consul.WithStrategy(consul.RoundRobin),
-		consul.WithInvokeTimeout(time.Second*5),
-		consul.WithRetry(3, time.Second),
-		consul.WithTags([]string{"api", "v1"}),
-	)
-
 	// HTTP 处理函数
 	mux := http.NewServeMux()
 	mux.HandleFunc("/orders/create", func(w http.ResponseWriter, r *http.Request) {
@@ -470,11 +461,16 @@ func startOrderService(wg *sync.WaitGroup, client *consul.Client, logger *log.Lo
 			return
 		}
 
-		// 2. 创建订单号
+		// 2. 创建订单号，序列号跨实例不重复
+		seq, err := orderService.orderSeq.Next()
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to generate order ID: %v", err), http.StatusInternalServerError)
+			return
+		}
 		orderID := fmt.Sprintf("%s-%s-%d",
 			orderService.config.OrderPrefix,
 			userID,
-			time.Now().Unix(),
+			seq,
 		)
 
 		// 3. 调用支付服务处理支付
@@ -503,10 +499,7 @@ func startOrderService(wg *sync.WaitGroup, client *consul.Client, logger *log.Lo
 		})
 	})
 
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]string{"status": "healthy"})
-	})
+	consul.MountHealthEndpoints(mux, nil)
 
 	// HTTP 服务器
 	server := &http.Server{
@@ -0,0 +1,92 @@
+package consul
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// ServicePolicy 服务自注册所需的最小ACL策略：
+// 对自身服务的写权限（注册、注销、更新TTL检查）和对配置前缀的读权限
+type ServicePolicy struct {
+	Name         string // 策略名称
+	Service      string // 服务名称，授予service:write
+	ConfigPrefix string // 配置前缀，授予key_prefix:read，为空时不授予
+}
+
+// GenerateServicePolicy 根据服务配置生成最小ACL策略，configPrefix为空时默认为"config/<服务名>/"
+func GenerateServicePolicy(cfg *ServiceConfig, configPrefix string) (*ServicePolicy, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("service config cannot be nil")
+	}
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("service name cannot be empty")
+	}
+	if configPrefix == "" {
+		configPrefix = "config/" + cfg.Name + "/"
+	}
+	return &ServicePolicy{
+		Name:         "service-" + cfg.Name,
+		Service:      cfg.Name,
+		ConfigPrefix: configPrefix,
+	}, nil
+}
+
+// HCL 返回HCL格式的策略规则
+func (p *ServicePolicy) HCL() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "service %q {\n  policy = \"write\"\n}\n", p.Service)
+	if p.ConfigPrefix != "" {
+		fmt.Fprintf(&b, "\nkey_prefix %q {\n  policy = \"read\"\n}\n", p.ConfigPrefix)
+	}
+	return b.String()
+}
+
+// JSON 返回JSON格式的策略规则
+func (p *ServicePolicy) JSON() string {
+	type rule struct {
+		Policy string `json:"policy"`
+	}
+	rules := map[string]map[string]rule{
+		"service": {p.Service: {Policy: "write"}},
+	}
+	if p.ConfigPrefix != "" {
+		rules["key_prefix"] = map[string]rule{p.ConfigPrefix: {Policy: "read"}}
+	}
+	data, _ := json.MarshalIndent(rules, "", "  ")
+	return string(data)
+}
+
+// CreateServicePolicy 通过ACL API创建策略，同名策略已存在时更新其规则，需要acl:write权限
+func (c *Client) CreateServicePolicy(p *ServicePolicy, opts ...WriteOption) (*api.ACLPolicy, error) {
+	if p == nil {
+		return nil, fmt.Errorf("policy cannot be nil")
+	}
+
+	policy := &api.ACLPolicy{
+		Name:        p.Name,
+		Description: fmt.Sprintf("Self-registration policy for service %s", p.Service),
+		Rules:       p.HCL(),
+	}
+
+	existing, _, err := c.client.ACL().PolicyReadByName(p.Name, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy %s: %v", p.Name, err)
+	}
+	if existing != nil {
+		policy.ID = existing.ID
+		updated, _, err := c.client.ACL().PolicyUpdate(policy, c.writeOptions(opts...))
+		if err != nil {
+			return nil, fmt.Errorf("failed to update policy %s: %v", p.Name, err)
+		}
+		return updated, nil
+	}
+
+	created, _, err := c.client.ACL().PolicyCreate(policy, c.writeOptions(opts...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create policy %s: %v", p.Name, err)
+	}
+	return created, nil
+}
@@ -0,0 +1,145 @@
+package consul
+
+import (
+	"fmt"
+	"net"
+)
+
+// AddressPreference 定义自动探测地址时的优先策略
+type AddressPreference int
+
+const (
+	// PreferRoute 优先使用默认路由出口的地址，失败时回退到网卡枚举
+	PreferRoute AddressPreference = iota
+	// PreferPrivate 优先使用私有网段地址
+	PreferPrivate
+	// PreferPublic 优先使用公网地址
+	PreferPublic
+)
+
+// AddressDetectOptions 本机地址自动探测选项
+type AddressDetectOptions struct {
+	Interface     string            // 指定网卡名称，例如：eth0，为空则枚举所有网卡
+	AllowLoopback bool              // 是否允许使用回环地址
+	Prefer        AddressPreference // 地址优先策略
+	RouteTarget   string            // 路由探测的目标地址，默认8.8.8.8:80（不会真正发送数据）
+}
+
+// WithAddressDetect 设置服务注册时本机地址的自动探测选项
+func WithAddressDetect(opts *AddressDetectOptions) Option {
+	return func(c *Config) {
+		c.addressDetect = opts
+	}
+}
+
+// DetectLocalAddress 探测本机用于服务注册的IP地址
+func DetectLocalAddress(opts *AddressDetectOptions) (string, error) {
+	if opts == nil {
+		opts = &AddressDetectOptions{}
+	}
+
+	// 未指定网卡时优先根据路由探测出口地址
+	if opts.Interface == "" && opts.Prefer == PreferRoute {
+		if ip, err := routeAddress(opts.RouteTarget); err == nil && (opts.AllowLoopback || !ip.IsLoopback()) {
+			return ip.String(), nil
+		}
+	}
+
+	ips, err := interfaceAddresses(opts.Interface, opts.AllowLoopback)
+	if err != nil {
+		return "", err
+	}
+	if len(ips) == 0 {
+		if opts.Interface != "" {
+			return "", fmt.Errorf("no usable address found on interface %s", opts.Interface)
+		}
+		return "", fmt.Errorf("no usable local address found")
+	}
+
+	// 按优先策略选择，IPv4优先于IPv6
+	for _, v4 := range []bool{true, false} {
+		for _, ip := range ips {
+			if (ip.To4() != nil) != v4 {
+				continue
+			}
+			switch opts.Prefer {
+			case PreferPrivate:
+				if ip.IsPrivate() {
+					return ip.String(), nil
+				}
+			case PreferPublic:
+				if !ip.IsPrivate() && !ip.IsLoopback() {
+					return ip.String(), nil
+				}
+			default:
+				return ip.String(), nil
+			}
+		}
+	}
+
+	// 没有满足优先策略的地址，退而使用第一个可用地址
+	return ips[0].String(), nil
+}
+
+// routeAddress 通过UDP"连接"获取默认路由的出口地址
+func routeAddress(target string) (net.IP, error) {
+	if target == "" {
+		target = "8.8.8.8:80"
+	}
+
+	conn, err := net.Dial("udp", target)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	addr, ok := conn.LocalAddr().(*net.UDPAddr)
+	if !ok || addr.IP.IsUnspecified() {
+		return nil, fmt.Errorf("failed to determine route address")
+	}
+	return addr.IP, nil
+}
+
+// interfaceAddresses 枚举处于启用状态的网卡上的单播地址
+func interfaceAddresses(name string, allowLoopback bool) ([]net.IP, error) {
+	var ifaces []net.Interface
+	if name != "" {
+		iface, err := net.InterfaceByName(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get interface %s: %v", name, err)
+		}
+		ifaces = []net.Interface{*iface}
+	} else {
+		all, err := net.Interfaces()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list interfaces: %v", err)
+		}
+		ifaces = all
+	}
+
+	var ips []net.IP
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 {
+			continue
+		}
+		if iface.Flags&net.FlagLoopback != 0 && !allowLoopback {
+			continue
+		}
+
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok || ipNet.IP.IsLinkLocalUnicast() || ipNet.IP.IsUnspecified() {
+				continue
+			}
+			if ipNet.IP.IsLoopback() && !allowLoopback {
+				continue
+			}
+			ips = append(ips, ipNet.IP)
+		}
+	}
+	return ips, nil
+}
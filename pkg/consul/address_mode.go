@@ -0,0 +1,39 @@
+package consul
+
+import "github.com/hashicorp/consul/api"
+
+// AddressMode 定义调用器使用实例的哪个地址，对应服务或节点TaggedAddresses中的名称
+type AddressMode string
+
+const (
+	// AddressDefault 使用服务地址，未设置时使用节点地址（默认）
+	AddressDefault AddressMode = ""
+	// AddressLAN 使用LAN地址
+	AddressLAN AddressMode = "lan"
+	// AddressWAN 使用WAN地址，用于跨越网络边界调用
+	AddressWAN AddressMode = "wan"
+	// AddressVirtual 使用Consul分配的虚拟IP，用于透明代理环境
+	AddressVirtual AddressMode = "consul-virtual"
+)
+
+// AddressTag 使用TaggedAddresses中自定义名称的地址
+func AddressTag(tag string) AddressMode {
+	return AddressMode(tag)
+}
+
+// WithAddressMode 设置调用器使用实例的哪个地址，实例未设置对应地址时使用服务地址
+func WithAddressMode(mode AddressMode) InvokerOption {
+	return func(i *ServiceInvoker) {
+		i.addressMode = mode
+	}
+}
+
+// instanceAddr 按地址模式返回实例的host:port地址
+func (i *ServiceInvoker) instanceAddr(entry *api.ServiceEntry) string {
+	if i.addressMode != AddressDefault {
+		if addr := taggedAddress(entry, string(i.addressMode)); addr != "" {
+			return addr
+		}
+	}
+	return newInstance(entry).Addr()
+}
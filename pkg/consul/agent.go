@@ -0,0 +1,74 @@
+package consul
+
+import (
+	"fmt"
+	"sort"
+)
+
+// LocalService 描述注册在本地Agent上的服务
+type LocalService struct {
+	ID      string            `json:"id"`      // 服务实例ID
+	Name    string            `json:"name"`    // 服务名称
+	Address string            `json:"address"` // 服务地址
+	Port    int               `json:"port"`    // 服务端口
+	Tags    []string          `json:"tags"`    // 服务标签
+	Meta    map[string]string `json:"meta"`    // 服务元数据
+	Checks  []*LocalCheck     `json:"checks"`  // 服务的健康检查
+}
+
+// LocalCheck 描述本地Agent上的健康检查
+type LocalCheck struct {
+	ID     string `json:"id"`     // 检查ID
+	Name   string `json:"name"`   // 检查名称
+	Type   string `json:"type"`   // 检查类型，例如：http、tcp、ttl
+	Status string `json:"status"` // 检查状态：passing、warning、critical
+	Output string `json:"output"` // 最近一次检查的输出
+}
+
+// LocalServices 获取本地Agent上注册的服务及其健康检查，按服务ID排序
+func (c *Client) LocalServices() ([]*LocalService, error) {
+	services, err := c.client.Agent().Services()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list agent services: %v", err)
+	}
+
+	checks, err := c.client.Agent().Checks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list agent checks: %v", err)
+	}
+
+	result := make([]*LocalService, 0, len(services))
+	byID := make(map[string]*LocalService, len(services))
+	for id, svc := range services {
+		local := &LocalService{
+			ID:      id,
+			Name:    svc.Service,
+			Address: svc.Address,
+			Port:    svc.Port,
+			Tags:    svc.Tags,
+			Meta:    svc.Meta,
+		}
+		byID[id] = local
+		result = append(result, local)
+	}
+
+	for _, check := range checks {
+		local, ok := byID[check.ServiceID]
+		if !ok {
+			continue
+		}
+		local.Checks = append(local.Checks, &LocalCheck{
+			ID:     check.CheckID,
+			Name:   check.Name,
+			Type:   check.Type,
+			Status: check.Status,
+			Output: check.Output,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	for _, local := range result {
+		sort.Slice(local.Checks, func(i, j int) bool { return local.Checks[i].ID < local.Checks[j].ID })
+	}
+	return result, nil
+}
@@ -0,0 +1,116 @@
+package consul
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// AgentCacheOptions Consul 1.7+的Agent缓存选项，只对支持缓存的端点生效（如健康实例、服务目录），
+// 其他端点忽略；强一致查询不使用缓存
+type AgentCacheOptions struct {
+	MaxAge       time.Duration // 缓存结果的最大存活时间，超过后Agent重新从服务器获取，0表示由Agent决定
+	StaleIfError time.Duration // 服务器不可用时允许返回的过期结果的最大存活时间
+}
+
+// WithAgentCache 让查询默认使用Agent缓存，降低Consul服务器的负载
+func WithAgentCache(opts AgentCacheOptions) Option {
+	return func(c *Config) {
+		c.agentCache = &opts
+	}
+}
+
+// WithQueryCache 让单次查询使用Agent缓存，maxAge为0时由Agent决定
+func WithQueryCache(maxAge time.Duration) QueryOption {
+	return func(q *api.QueryOptions) {
+		q.UseCache = true
+		q.MaxAge = maxAge
+	}
+}
+
+// WithQueryNoCache 单次查询不使用Agent缓存
+func WithQueryNoCache() QueryOption {
+	return func(q *api.QueryOptions) {
+		q.UseCache = false
+		q.MaxAge = 0
+		q.StaleIfError = 0
+	}
+}
+
+// CacheInfo 查询结果的Agent缓存信息，取自响应的X-Cache和Age头
+type CacheInfo struct {
+	Cached bool          // 响应是否经过Agent缓存
+	Hit    bool          // 是否命中缓存
+	Age    time.Duration // 缓存结果的存活时间
+}
+
+type cacheInfoKey struct{}
+
+// WithQueryCacheInfo 将单次查询的缓存命中信息写入info，需放在WithQueryContext之后
+func WithQueryCacheInfo(info *CacheInfo) QueryOption {
+	return func(q *api.QueryOptions) {
+		*q = *q.WithContext(context.WithValue(q.Context(), cacheInfoKey{}, info))
+	}
+}
+
+// AgentCacheStats Agent缓存的命中统计，只统计带X-Cache头的响应
+type AgentCacheStats struct {
+	Hits   uint64 // 命中次数
+	Misses uint64 // 未命中次数
+}
+
+// AgentCacheStats 返回本客户端查询的Agent缓存命中统计
+func (c *Client) AgentCacheStats() AgentCacheStats {
+	if c.cacheStats == nil {
+		return AgentCacheStats{}
+	}
+	return AgentCacheStats{Hits: c.cacheStats.hits.Load(), Misses: c.cacheStats.misses.Load()}
+}
+
+// applyAgentCache 将客户端默认的缓存选项写入QueryOptions
+func applyAgentCache(q *api.QueryOptions, opts *AgentCacheOptions) {
+	if opts == nil {
+		return
+	}
+	q.UseCache = true
+	q.MaxAge = opts.MaxAge
+	q.StaleIfError = opts.StaleIfError
+}
+
+// cacheStatsTransport 读取响应的X-Cache和Age头，统计命中率并写入查询的CacheInfo
+type cacheStatsTransport struct {
+	base   http.RoundTripper
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// RoundTrip 实现http.RoundTripper
+func (t *cacheStatsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	xcache := resp.Header.Get("X-Cache")
+	if xcache == "" {
+		return resp, nil
+	}
+	hit := xcache == "HIT"
+	if hit {
+		t.hits.Add(1)
+	} else {
+		t.misses.Add(1)
+	}
+	if info, ok := req.Context().Value(cacheInfoKey{}).(*CacheInfo); ok && info != nil {
+		info.Cached = true
+		info.Hit = hit
+		if age, err := strconv.Atoi(resp.Header.Get("Age")); err == nil {
+			info.Age = time.Duration(age) * time.Second
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,53 @@
+package consul
+
+import (
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// WithAgentRateLimit 限制本客户端发往Consul的请求速率（每秒请求数），允许rps向上取整的突发，
+// 超出时请求排队等待，防止失控的监听或服务发现循环压垮共享的Agent。重试产生的请求同样计入
+func WithAgentRateLimit(rps float64) Option {
+	return func(c *Config) {
+		c.agentRate = rps
+	}
+}
+
+// rateLimitTransport 按固定速率放行请求的传输层
+type rateLimitTransport struct {
+	base     http.RoundTripper
+	interval time.Duration // 两次请求的平均间隔
+	burst    int           // 允许的突发请求数
+
+	mu  sync.Mutex
+	tat time.Time // 下一个请求按平均速率应到达的时间
+}
+
+// newRateLimitTransport 创建限速传输层
+func newRateLimitTransport(base http.RoundTripper, rps float64) http.RoundTripper {
+	return &rateLimitTransport{
+		base:     base,
+		interval: time.Duration(float64(time.Second) / rps),
+		burst:    max(1, int(math.Ceil(rps))),
+	}
+}
+
+// RoundTrip 等待到请求被放行后转发，请求上下文结束时返回错误
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	now := time.Now()
+	t.mu.Lock()
+	if t.tat.Before(now) {
+		t.tat = now
+	}
+	allowAt := t.tat.Add(-time.Duration(t.burst-1) * t.interval)
+	t.tat = t.tat.Add(t.interval)
+	t.mu.Unlock()
+
+	if wait := allowAt.Sub(now); wait > 0 && !sleepContext(req.Context(), wait) {
+		closeRequestBody(req)
+		return nil, req.Context().Err()
+	}
+	return t.base.RoundTrip(req)
+}
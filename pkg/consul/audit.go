@@ -0,0 +1,101 @@
+package consul
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+)
+
+// 审计日志记录的操作类型
+const (
+	AuditRegister    = "register"    // 注册服务
+	AuditDeregister  = "deregister"  // 注销服务
+	AuditMaintenance = "maintenance" // 开启维护模式
+)
+
+// AuditEntry 一条服务注册变更的审计记录
+type AuditEntry struct {
+	Time      time.Time `json:"time"`             // 操作时间
+	Action    string    `json:"action"`           // 操作类型
+	ServiceID string    `json:"service_id"`       // 服务实例ID
+	Service   string    `json:"service"`          // 服务名称，非本客户端注册的实例可能为空
+	Node      string    `json:"node,omitempty"`   // 目标节点，仅注销其他节点上的实例时设置
+	Reason    string    `json:"reason,omitempty"` // 操作原因
+	Error     string    `json:"error,omitempty"`  // 操作失败时的错误
+	Host      string    `json:"host"`             // 执行操作的主机名
+	User      string    `json:"user"`             // 执行操作的系统用户
+	PID       int       `json:"pid"`              // 执行操作的进程ID
+}
+
+// WithAuditLog 将本客户端执行的注册、注销和维护模式操作追加写入KV前缀，
+// 每次操作一个键，键名按时间排序，用于事后分析注册抖动
+func WithAuditLog(prefix string) Option {
+	return func(c *Config) {
+		c.auditPrefix = strings.TrimSuffix(prefix, "/") + "/"
+	}
+}
+
+// audit 写入一条审计记录，未开启审计日志时不做任何事，写入失败时仅记录日志
+func (c *Client) audit(entry AuditEntry, err error) {
+	if c.config.auditPrefix == "" {
+		return
+	}
+
+	if entry.Service == "" {
+		c.mu.RLock()
+		if cfg, ok := c.services[entry.ServiceID]; ok {
+			entry.Service = cfg.Name
+		}
+		c.mu.RUnlock()
+	}
+	entry.Time = time.Now()
+	entry.Host, _ = os.Hostname()
+	if u, uerr := user.Current(); uerr == nil {
+		entry.User = u.Username
+	}
+	entry.PID = os.Getpid()
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	data, merr := json.Marshal(entry)
+	if merr != nil {
+		c.logger.Printf("Failed to marshal audit entry: %v", merr)
+		return
+	}
+
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	key := fmt.Sprintf("%s%019d-%s", c.config.auditPrefix, entry.Time.UnixNano(), hex.EncodeToString(suffix))
+	if _, werr := c.CAS(key, data, 0); werr != nil {
+		c.logger.Printf("Failed to write audit entry for %s %s: %v", entry.Action, entry.ServiceID, werr)
+	}
+}
+
+// AuditLog 按时间顺序返回审计前缀下的所有记录，未开启审计日志时返回错误
+func (c *Client) AuditLog(opts ...QueryOption) ([]AuditEntry, error) {
+	if c.config.auditPrefix == "" {
+		return nil, fmt.Errorf("audit log is not enabled")
+	}
+
+	pairs, _, err := c.client.KV().List(c.config.auditPrefix, c.kvQueryOptions(c.config.auditPrefix, opts...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit log: %v", err)
+	}
+
+	entries := make([]AuditEntry, 0, len(pairs))
+	for _, pair := range pairs {
+		var entry AuditEntry
+		if err := json.Unmarshal(pair.Value, &entry); err != nil {
+			c.logger.Printf("Skipping invalid audit entry %s: %v", pair.Key, err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
@@ -0,0 +1,91 @@
+package consul
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// BackoffPolicy 定义带上限和随机抖动的指数退避策略
+type BackoffPolicy struct {
+	Initial    time.Duration // 首次重试间隔，小于等于0时为1秒
+	Max        time.Duration // 最大重试间隔，小于等于0时为30秒
+	Multiplier float64       // 每次重试间隔的增长倍数，小于1时按2处理
+	Jitter     float64       // 随机抖动比例（0~1），避免大量客户端同时重连
+}
+
+const (
+	defaultBackoffInitial = time.Second      // 未设置初始间隔时使用的默认值
+	defaultBackoffMax     = time.Second * 30 // 未设置最大间隔时使用的默认值
+)
+
+// WithBackoffPolicy 设置连接Consul及监听重试时的退避策略，
+// 默认以WithRetryTime为初始间隔、2倍增长、上限30秒、20%抖动
+func WithBackoffPolicy(policy BackoffPolicy) Option {
+	return func(c *Config) {
+		c.backoff = &policy
+	}
+}
+
+// Delay 返回第attempt次（从0开始）重试前的等待时间
+func (p BackoffPolicy) Delay(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+
+	initial, maxDelay := p.Initial, p.Max
+	if initial <= 0 {
+		initial = defaultBackoffInitial
+	}
+	if maxDelay <= 0 {
+		maxDelay = defaultBackoffMax
+	}
+
+	// 在转换为time.Duration前截断，避免重试次数较多时溢出
+	delay := math.Min(float64(initial)*math.Pow(multiplier, float64(attempt)), float64(maxDelay))
+
+	if p.Jitter > 0 {
+		jitter := math.Min(p.Jitter, 1)
+		delay = delay * (1 - jitter + 2*jitter*rand.Float64())
+	}
+	return time.Duration(delay)
+}
+
+// backoffPolicy 返回客户端的退避策略，initial大于0时覆盖初始间隔，
+// 未设置的初始间隔使用WithRetryTime
+func (c *Config) backoffPolicy(initial time.Duration) BackoffPolicy {
+	policy := BackoffPolicy{
+		Initial:    c.retryTime,
+		Max:        defaultBackoffMax,
+		Multiplier: 2,
+		Jitter:     0.2,
+	}
+	if c.backoff != nil {
+		policy = *c.backoff
+	}
+	if initial > 0 {
+		policy.Initial = initial
+	}
+	if policy.Initial <= 0 {
+		policy.Initial = c.retryTime
+	}
+	if policy.Max <= 0 {
+		policy.Max = defaultBackoffMax
+	}
+	return policy
+}
+
+// sleepContext 等待指定时间，上下文结束时提前返回false
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,129 @@
+package consul
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BindConfig 根据结构体标签从多个KV键填充配置：
+//
+//	Host string `consul:"database/host" default:"localhost" env:"DB_HOST"`
+//
+// 优先级为环境变量 > Consul > default。基本类型、time.Duration和[]string（逗号分隔）直接转换，
+// 其他类型按JSON解析；没有标签的嵌套结构体会递归绑定
+func (c *Client) BindConfig(config interface{}, opts ...QueryOption) error {
+	v := reflect.ValueOf(config)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("config must be a non-nil pointer to struct")
+	}
+	return c.bindStruct(v.Elem(), opts)
+}
+
+// bindStruct 逐字段绑定结构体
+func (c *Client) bindStruct(v reflect.Value, opts []QueryOption) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+
+		key, hasKey := field.Tag.Lookup("consul")
+		def, hasDefault := field.Tag.Lookup("default")
+		env, hasEnv := field.Tag.Lookup("env")
+		if !hasKey && !hasDefault && !hasEnv {
+			if fv.Kind() == reflect.Struct && fv.Type() != reflect.TypeOf(time.Time{}) {
+				if err := c.bindStruct(fv, opts); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		var value string
+		var found bool
+		if hasEnv {
+			value, found = os.LookupEnv(env)
+		}
+		if !found && hasKey && key != "" {
+			data, err := c.Get(key, opts...)
+			if err != nil {
+				return fmt.Errorf("failed to bind field %s: %v", field.Name, err)
+			}
+			if data != nil {
+				value, found = string(data), true
+			}
+		}
+		if !found && hasDefault {
+			value, found = def, true
+		}
+		if !found {
+			continue
+		}
+
+		if err := setField(fv, value); err != nil {
+			return fmt.Errorf("failed to bind field %s: %v", field.Name, err)
+		}
+	}
+	return nil
+}
+
+// setField 将字符串值转换为字段类型并赋值
+func setField(fv reflect.Value, value string) error {
+	if fv.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	default:
+		if fv.Type() == reflect.TypeOf([]string(nil)) && !strings.HasPrefix(strings.TrimSpace(value), "[") {
+			var items []string
+			for _, item := range strings.Split(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+			fv.Set(reflect.ValueOf(items))
+			return nil
+		}
+		return json.Unmarshal([]byte(value), fv.Addr().Interface())
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package consul
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// BundleSpec 描述一个应用启动时需要的Consul资源，由Bootstrap一次完成
+type BundleSpec struct {
+	Client   *Client                    // 使用的客户端，需通过NewClient创建
+	Services []*ServiceConfig           // 需要注册的服务
+	Configs  map[string][]byte          // 初始配置，只在键不存在时写入，已有的值不会被覆盖
+	Watches  []WatchSpec                // 需要监听的配置
+	Invokers map[string][]InvokerOption // 需要创建的调用器，key为下游服务名
+}
+
+// WatchSpec 单个配置监听，参数与WatchConfig相同
+type WatchSpec struct {
+	Key     string        // 配置键
+	Target  interface{}   // 解析目标，需为指针
+	Options *WatchOptions // 监听选项，为nil时使用默认值
+}
+
+// Bundle Bootstrap创建的资源
+type Bundle struct {
+	Client   *Client                    // 使用的客户端
+	Invokers map[string]*ServiceInvoker // 创建的调用器，key为下游服务名
+
+	services []string // 注册的服务ID
+	seeded   []string // 本次写入的初始配置键
+	watches  []string // 启动的监听键
+}
+
+// Bootstrap 按顺序写入缺失的初始配置、启动配置监听、注册服务并创建调用器，
+// 服务在配置加载完成后才注册，避免未就绪的实例被发现。ctx同时限制配置的初始加载，
+// InitialWait的监听在ctx结束时停止等待。任一步骤失败或ctx结束时
+// 回滚已完成的步骤：注销服务、停止监听并删除本次写入的初始配置，返回的错误包含回滚中的错误
+func Bootstrap(ctx context.Context, spec BundleSpec) (*Bundle, error) {
+	c := spec.Client
+	if c == nil {
+		return nil, fmt.Errorf("client cannot be nil")
+	}
+
+	watched := make(map[string]bool, len(spec.Watches))
+	for _, w := range spec.Watches {
+		if watched[w.Key] {
+			return nil, fmt.Errorf("duplicate watch for key %s", w.Key)
+		}
+		watched[w.Key] = true
+	}
+
+	b := &Bundle{Client: c, Invokers: make(map[string]*ServiceInvoker, len(spec.Invokers))}
+	fail := func(err error) (*Bundle, error) {
+		if rollbackErr := b.rollback(true); rollbackErr != nil {
+			return nil, fmt.Errorf("failed to bootstrap: %w (rollback: %v)", err, rollbackErr)
+		}
+		return nil, fmt.Errorf("failed to bootstrap: %w", err)
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(spec.Configs)) {
+		if err := ctx.Err(); err != nil {
+			return fail(err)
+		}
+		created, err := c.CAS(key, spec.Configs[key], 0)
+		if err != nil {
+			return fail(fmt.Errorf("failed to seed config %s: %v", key, err))
+		}
+		if created {
+			b.seeded = append(b.seeded, key)
+		}
+	}
+
+	for _, w := range spec.Watches {
+		if err := ctx.Err(); err != nil {
+			return fail(err)
+		}
+		if err := c.watchConfig(ctx, w.Key, w.Target, w.Options); err != nil {
+			return fail(fmt.Errorf("failed to watch config %s: %v", w.Key, err))
+		}
+		b.watches = append(b.watches, w.Key)
+	}
+
+	for _, cfg := range spec.Services {
+		if err := ctx.Err(); err != nil {
+			return fail(err)
+		}
+		if err := c.RegisterService(cfg, WithWriteContext(ctx)); err != nil {
+			name := ""
+			if cfg != nil {
+				name = cfg.Name
+			}
+			return fail(fmt.Errorf("failed to register service %s: %v", name, err))
+		}
+		b.services = append(b.services, cfg.ID)
+	}
+
+	for name, opts := range spec.Invokers {
+		b.Invokers[name] = c.NewServiceInvoker(name, opts...)
+	}
+
+	c.logger.Printf("Bootstrap completed: %d configs seeded, %d watches, %d services, %d invokers",
+		len(b.seeded), len(b.watches), len(b.services), len(b.Invokers))
+	return b, nil
+}
+
+// Invoker 返回下游服务的调用器，未在BundleSpec中声明时返回nil
+func (b *Bundle) Invoker(name string) *ServiceInvoker {
+	return b.Invokers[name]
+}
+
+// Teardown 注销Bootstrap注册的服务并停止其启动的监听，初始配置保留
+func (b *Bundle) Teardown() error {
+	return b.rollback(false)
+}
+
+// rollback 按与启动相反的顺序释放资源，deleteSeeded为true时同时删除本次写入的初始配置
+func (b *Bundle) rollback(deleteSeeded bool) error {
+	var errs []string
+	for _, id := range slices.Backward(b.services) {
+		if err := b.Client.DeregisterService(id); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	b.services = nil
+
+	for _, key := range slices.Backward(b.watches) {
+		// 监听可能已被StopWatch停止，忽略未找到的错误
+		b.Client.StopWatch(key)
+	}
+	b.watches = nil
+
+	if deleteSeeded {
+		for _, key := range slices.Backward(b.seeded) {
+			if err := b.Client.Delete(key); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+		b.seeded = nil
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+// cache.go
+package consul
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// WithLocalCache 将监听配置最近一次成功加载的值持久化到dir目录，
+// 启动时Consul不可达则从缓存加载，使服务在Consul故障期间仍能以旧配置启动。
+// 启用后NewClient在连接重试耗尽时不返回错误，而是返回客户端，监听在Consul恢复后自动继续
+func WithLocalCache(dir string) Option {
+	return func(c *Config) {
+		c.localCache = dir
+	}
+}
+
+// cachePath 返回键对应的缓存文件路径
+func (c *Client) cachePath(key string) string {
+	return filepath.Join(c.config.localCache, url.PathEscape(key))
+}
+
+// saveCache 原子写入键的缓存，未启用缓存时不做任何操作
+func (c *Client) saveCache(key string, value []byte) {
+	if c.config.localCache == "" {
+		return
+	}
+
+	if err := os.MkdirAll(c.config.localCache, 0o755); err != nil {
+		c.logger.Printf("Failed to create cache dir %s: %v", c.config.localCache, err)
+		return
+	}
+	path := c.cachePath(key)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, value, 0o600); err != nil {
+		c.logger.Printf("Failed to write cache for %s: %v", key, err)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		c.logger.Printf("Failed to write cache for %s: %v", key, err)
+	}
+}
+
+// removeCache 删除键的缓存
+func (c *Client) removeCache(key string) {
+	if c.config.localCache == "" {
+		return
+	}
+	if err := os.Remove(c.cachePath(key)); err != nil && !os.IsNotExist(err) {
+		c.logger.Printf("Failed to remove cache for %s: %v", key, err)
+	}
+}
+
+// loadCache 从本地缓存解析配置
+func (c *Client) loadCache(key string, config interface{}) error {
+	if c.config.localCache == "" {
+		return fmt.Errorf("local cache is not enabled")
+	}
+	data, err := os.ReadFile(c.cachePath(key))
+	if err != nil {
+		return fmt.Errorf("failed to read cache: %v", err)
+	}
+	if err := json.Unmarshal(data, config); err != nil {
+		return fmt.Errorf("failed to parse cache: %v", err)
+	}
+	return nil
+}
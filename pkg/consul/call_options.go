@@ -0,0 +1,168 @@
+package consul
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// CallOption 定义单次调用的选项
+type CallOption func(*callOptions)
+
+// callOptions 单次调用的选项
+type callOptions struct {
+	ctx     context.Context
+	timeout time.Duration
+	stream  bool
+	body    io.Reader
+}
+
+// WithCallTimeout 设置单次调用每次尝试的总超时时间，覆盖调用器的WithInvokeTimeout设置
+func WithCallTimeout(timeout time.Duration) CallOption {
+	return func(o *callOptions) {
+		o.timeout = timeout
+	}
+}
+
+// WithCallContext 设置单次调用的上下文，上下文取消时调用立即结束且不再重试
+func WithCallContext(ctx context.Context) CallOption {
+	return func(o *callOptions) {
+		o.ctx = ctx
+	}
+}
+
+// WithStreaming 以流式方式调用，超时只限制收到响应头之前的时间，响应体可以持续读取，
+// 适用于SSE等长连接响应；携带Upgrade请求头（如WebSocket）的调用自动按流式处理，
+// 101响应的Body同时实现io.Writer，可用于双向通信
+func WithStreaming() CallOption {
+	return func(o *callOptions) {
+		o.stream = true
+	}
+}
+
+// WithRequestBody 以流式方式发送请求体，忽略Call的body参数，适用于大文件上传等无需整体缓存的场景。
+// 请求体只能读取一次，调用失败时不重试，也不会镜像到影子流量，降级处理收到的body为空
+func WithRequestBody(body io.Reader) CallOption {
+	return func(o *callOptions) {
+		o.body = body
+	}
+}
+
+// WithConnectTimeout 设置建立连接的超时时间，与调用的总超时时间分开控制
+func WithConnectTimeout(timeout time.Duration) InvokerOption {
+	return func(i *ServiceInvoker) {
+		i.connectTimeout = timeout
+	}
+}
+
+// newCallOptions 应用单次调用选项
+func newCallOptions(opts []CallOption) *callOptions {
+	o := &callOptions{ctx: context.Background()}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// newTransport 根据连接超时创建HTTP传输层
+func newTransport(connectTimeout time.Duration) http.RoundTripper {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if connectTimeout > 0 {
+		dialer := &net.Dialer{
+			Timeout:   connectTimeout,
+			KeepAlive: 30 * time.Second,
+		}
+		transport.DialContext = dialer.DialContext
+		transport.TLSHandshakeTimeout = connectTimeout
+	}
+	return transport
+}
+
+// do 以独立的超时执行一次请求，响应体关闭时释放超时上下文
+func (i *ServiceInvoker) do(req *http.Request, o *callOptions, timeout time.Duration) (*http.Response, error) {
+	if o.timeout > 0 {
+		timeout = o.timeout
+	}
+
+	var ctx context.Context
+	var cancel context.CancelFunc
+	var headerTimer *time.Timer
+	stream := o.stream || isUpgrade(req.Header)
+	switch {
+	case timeout > 0 && stream:
+		// 流式调用只限制等待响应头的时间
+		ctx, cancel = context.WithCancel(o.ctx)
+		headerTimer = time.AfterFunc(timeout, cancel)
+	case timeout > 0:
+		ctx, cancel = context.WithTimeout(o.ctx, timeout)
+	default:
+		ctx, cancel = context.WithCancel(o.ctx)
+	}
+
+	// 每次尝试使用新的请求体，保证重试时请求体完整
+	attempt := req.Clone(ctx)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		attempt.Body = body
+	}
+
+	resp, err := i.httpClient.Do(attempt)
+	if headerTimer != nil && !headerTimer.Stop() && err == nil {
+		// 计时器已触发，请求上下文已被取消
+		resp.Body.Close()
+		err = context.DeadlineExceeded
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if rw, ok := resp.Body.(io.ReadWriteCloser); ok && resp.StatusCode == http.StatusSwitchingProtocols {
+		resp.Body = &cancelOnCloseRW{cancelOnClose: cancelOnClose{ReadCloser: rw, cancel: cancel}, w: rw}
+		return resp, nil
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// isUpgrade 判断请求是否为协议升级请求
+func isUpgrade(h http.Header) bool {
+	for _, v := range h.Values("Connection") {
+		for _, token := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), "upgrade") {
+				return h.Get("Upgrade") != ""
+			}
+		}
+	}
+	return false
+}
+
+// cancelOnClose 在响应体关闭时取消请求上下文
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close 关闭响应体并取消上下文
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// cancelOnCloseRW 协议升级后的双向连接，关闭时取消请求上下文
+type cancelOnCloseRW struct {
+	cancelOnClose
+	w io.Writer
+}
+
+// Write 向升级后的连接写入数据
+func (b *cancelOnCloseRW) Write(p []byte) (int, error) {
+	return b.w.Write(p)
+}
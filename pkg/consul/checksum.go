@@ -0,0 +1,127 @@
+package consul
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// ChecksumSuffix 是校验和所在键相对于配置键的后缀
+const ChecksumSuffix = ".sha256"
+
+// ErrChecksumMismatch 表示配置值与校验和不一致，可能是写入被截断或数据损坏
+var ErrChecksumMismatch = errors.New("config checksum mismatch")
+
+// ChecksumFailures 返回配置校验失败的累计次数，可用于监控告警
+func (c *Client) ChecksumFailures() uint64 {
+	return c.checksumFailures.Load()
+}
+
+// PutConfigChecked 在同一个事务中写入配置值及其SHA-256校验和（键为key+".sha256"）
+func (c *Client) PutConfigChecked(key string, value []byte, opts ...WriteOption) error {
+	if key == "" {
+		return fmt.Errorf("key cannot be empty")
+	}
+
+	w := c.kvWriteOptions(key, opts...)
+	if err := validateWrite(key, value, w); err != nil {
+		return err
+	}
+	ops := api.TxnOps{
+		{KV: &api.KVTxnOp{Verb: api.KVSet, Key: key, Value: value}},
+		{KV: &api.KVTxnOp{Verb: api.KVSet, Key: key + ChecksumSuffix, Value: []byte(checksum(value))}},
+	}
+	q := &api.QueryOptions{Datacenter: w.Datacenter, Token: w.Token}
+	c.invalidateKVCache(key)
+	c.invalidateKVCache(key + ChecksumSuffix)
+	ok, resp, _, err := c.client.Txn().Txn(ops, q.WithContext(w.Context()))
+	if err != nil || !ok {
+		c.releaseKVCache(key, key+ChecksumSuffix)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to put checked config: %v", err)
+	}
+	if !ok {
+		return fmt.Errorf("failed to put checked config: %s", txnErrors(resp))
+	}
+
+	c.logger.Printf("Checked config put for key: %s", key)
+	return nil
+}
+
+// GetConfigChecked 在同一个事务中读取配置值及其校验和并校验，
+// 键不存在时返回nil，校验失败时返回ErrChecksumMismatch
+func (c *Client) GetConfigChecked(key string, opts ...QueryOption) ([]byte, error) {
+	if key == "" {
+		return nil, fmt.Errorf("key cannot be empty")
+	}
+
+	ops := api.TxnOps{
+		{KV: &api.KVTxnOp{Verb: api.KVGetOrEmpty, Key: key}},
+		{KV: &api.KVTxnOp{Verb: api.KVGetOrEmpty, Key: key + ChecksumSuffix}},
+	}
+	ok, resp, _, err := c.client.Txn().Txn(ops, c.kvQueryOptions(key, opts...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get checked config: %v", err)
+	}
+	if !ok || len(resp.Results) != 2 {
+		return nil, fmt.Errorf("failed to get checked config: %s", txnErrors(resp))
+	}
+
+	pair, sum := resp.Results[0].KV, resp.Results[1].KV
+	if pair == nil || pair.ModifyIndex == 0 {
+		return nil, nil
+	}
+	var expected string
+	if sum != nil {
+		expected = string(sum.Value)
+	}
+	if err := c.verifyChecksum(key, pair.Value, expected); err != nil {
+		return nil, err
+	}
+	return pair.Value, nil
+}
+
+// verifyChecksum 校验配置值，失败时记录指标
+func (c *Client) verifyChecksum(key string, value []byte, expected string) error {
+	if checksum(value) == strings.TrimSpace(expected) {
+		return nil
+	}
+	c.checksumFailures.Add(1)
+	c.logger.Printf("Checksum verification failed for key: %s", key)
+	return fmt.Errorf("%w: %s", ErrChecksumMismatch, key)
+}
+
+// checksum 计算SHA-256校验和的十六进制表示
+func checksum(value []byte) string {
+	sum := sha256.Sum256(value)
+	return hex.EncodeToString(sum[:])
+}
+
+// txnErrors 拼接事务错误信息
+func txnErrors(resp *api.TxnResponse) string {
+	if resp == nil || len(resp.Errors) == 0 {
+		return "transaction rolled back"
+	}
+	msgs := make([]string, 0, len(resp.Errors))
+	for _, e := range resp.Errors {
+		msgs = append(msgs, fmt.Sprintf("op %d: %s", e.OpIndex, e.What))
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// fetchChecksum 读取配置键对应的校验和
+func (c *Client) fetchChecksum(key string) (string, error) {
+	pair, _, err := c.client.KV().Get(key+ChecksumSuffix, c.kvQueryOptions(key))
+	if err != nil {
+		return "", fmt.Errorf("failed to get checksum: %v", err)
+	}
+	if pair == nil {
+		return "", nil
+	}
+	return string(pair.Value), nil
+}
@@ -5,10 +5,16 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net"
+	"net/http"
 	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/hashicorp/consul/api"
+	"golang.org/x/sync/singleflight"
 )
 
 // Client 是Consul客户端的封装
@@ -18,6 +24,36 @@ type Client struct {
 	config *Config
 	ctx    context.Context    // 用于控制后台任务的上下文
 	cancel context.CancelFunc // 用于取消上下文
+
+	failover *failoverTransport // 多地址故障切换，未配置多个地址时为nil
+
+	mu       sync.RWMutex
+	services map[string]*ServiceConfig // 通过本客户端注册的服务，key为服务ID
+	watches  map[string]*watchState    // 活跃的配置监听，key为KV键
+
+	checksumFailures atomic.Uint64 // 配置校验失败次数
+
+	transportMu sync.Mutex
+	transports  map[time.Duration]http.RoundTripper // 调用器共享的传输层，key为建连超时
+
+	lookups   singleflight.Group             // 合并并发的健康实例查询
+	prewarmed map[string][]*api.ServiceEntry // 预加载的健康实例，由c.mu保护
+
+	eventsMu    sync.Mutex
+	subscribers []chan Event // 内部事件的订阅者
+
+	workersMu sync.Mutex
+	workers   map[string]int // 运行中的后台任务数量，key为任务名称
+
+	kvCache    *kvCache             // KV读缓存，未启用时为nil
+	cacheStats *cacheStatsTransport // Agent缓存命中统计
+
+	rules map[string][]ConfigRule // 键前缀对应的配置校验规则，由c.mu保护
+
+	localDC    string          // 从本地Agent获取的数据中心，由c.mu保护
+	coordCache coordinateCache // 节点坐标缓存，由c.mu保护
+
+	login *loginSession // 通过认证方法登录换取的Token，未启用时为nil
 }
 
 // Config 是Consul客户端的配置
@@ -32,12 +68,42 @@ type Config struct {
 	maxRetries  int                // 最大重试次数
 	logger      *log.Logger        // 自定义日志器
 	credentials *api.HttpBasicAuth // HTTP Basic Auth 认证信息
+
+	addressDetect       *AddressDetectOptions // 服务注册时本机地址的自动探测选项
+	autoDeregister      bool                  // 退出时是否自动注销服务
+	exitAfterDeregister bool                  // 退出信号触发的注销完成后是否重新投递信号使进程退出
+	consistency         ConsistencyMode       // 查询默认的一致性模式
+	defaultMeta         map[string]string     // 注册服务时默认合并的元数据
+	defaultTags         []string              // 注册服务时默认合并的标签
+	addresses           []string              // 多个Consul地址，用于故障切换
+	probeInterval       time.Duration         // 故障切换后探测主地址的间隔
+	backoff             *BackoffPolicy        // 重试退避策略
+
+	localCache string          // 监听配置的本地缓存目录
+	faults     *FaultInjection // 对Consul请求的故障注入
+
+	kvTokens map[string]string // KV前缀对应的ACL Token
+
+	auditPrefix string // 注册变更审计日志的KV前缀
+
+	headers http.Header // 发往Consul的请求附带的请求头
+	http2   bool        // 是否启用HTTP/2
+
+	retry     *RetryPolicy // 对Consul请求的重试策略
+	agentRate float64      // 发往Consul的每秒请求数上限，0表示不限制
+
+	kvCache    *string            // KV读缓存的前缀，nil表示不启用
+	agentCache *AgentCacheOptions // 查询默认使用的Agent缓存选项
+
+	dnsFallback string // HTTP API不可用时降级查询的Consul DNS地址
+
+	login *LoginOptions // 通过认证方法登录换取Token的选项
 }
 
 // Option 定义配置选项函数类型
 type Option func(*Config)
 
-// WithAddress 设置Consul地址
+// WithAddress 设置Consul地址，本机Agent通过unix socket暴露时使用"unix:///var/run/consul.sock"形式
 func WithAddress(address string) Option {
 	return func(c *Config) {
 		c.address = address
@@ -65,6 +131,13 @@ func WithScheme(scheme string) Option {
 	}
 }
 
+// WithHTTP2 启用HTTP/2连接Consul，需配合https协议，通过TLS协商，协商失败时回退到HTTP/1.1
+func WithHTTP2() Option {
+	return func(c *Config) {
+		c.http2 = true
+	}
+}
+
 // WithDatacenter 设置数据中心
 func WithDatacenter(datacenter string) Option {
 	return func(c *Config) {
@@ -110,6 +183,35 @@ func WithBasicAuth(username, password string) Option {
 	}
 }
 
+// WithUserAgent 设置发往Consul的请求的User-Agent，便于代理和审计日志识别应用及版本
+func WithUserAgent(userAgent string) Option {
+	return WithHeader("User-Agent", userAgent)
+}
+
+// WithHeader 为发往Consul的所有请求添加请求头，可多次调用添加多个
+func WithHeader(key, value string) Option {
+	return func(c *Config) {
+		if c.headers == nil {
+			c.headers = make(http.Header)
+		}
+		c.headers.Set(key, value)
+	}
+}
+
+// WithDefaultMeta 设置注册服务时默认合并的元数据，服务自身的同名字段优先
+func WithDefaultMeta(meta map[string]string) Option {
+	return func(c *Config) {
+		c.defaultMeta = meta
+	}
+}
+
+// WithDefaultTags 设置注册服务时默认合并的标签
+func WithDefaultTags(tags []string) Option {
+	return func(c *Config) {
+		c.defaultTags = tags
+	}
+}
+
 // NewClient 创建新的Consul客户端
 func NewClient(opts ...Option) (*Client, error) {
 	// 初始化默认配置
@@ -128,6 +230,10 @@ func NewClient(opts ...Option) (*Client, error) {
 		opt(cfg)
 	}
 
+	if cfg.login != nil && cfg.token != "" {
+		return nil, fmt.Errorf("login cannot be used with a static token")
+	}
+
 	// 创建上下文
 	ctx, cancel := context.WithCancel(context.Background())
 
@@ -139,6 +245,52 @@ func NewClient(opts ...Option) (*Client, error) {
 	config.Datacenter = cfg.datacenter
 	config.WaitTime = cfg.waitTime
 	config.HttpAuth = cfg.credentials
+	if cfg.http2 {
+		config.Transport.ForceAttemptHTTP2 = true
+	}
+
+	// unix socket地址在此处理而不交给api包，使故障注入等传输层包装仍然生效
+	if socket, ok := strings.CutPrefix(cfg.address, "unix://"); ok {
+		if len(cfg.addresses) > 1 {
+			cancel()
+			return nil, fmt.Errorf("unix socket address cannot be used with multiple addresses")
+		}
+		config.Address = "localhost"
+		config.Transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		}
+	}
+
+	// 按配置逐层包装传输层：缓存统计、限速、多地址故障切换、故障注入、重试和登录Token
+	httpClient, err := api.NewHttpClient(config.Transport, config.TLSConfig)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to create consul http client: %v", err)
+	}
+	cacheStats := &cacheStatsTransport{base: httpClient.Transport}
+	httpClient.Transport = cacheStats
+	if cfg.agentRate > 0 {
+		httpClient.Transport = newRateLimitTransport(httpClient.Transport, cfg.agentRate)
+	}
+	var failover *failoverTransport
+	if len(cfg.addresses) > 1 {
+		failover = newFailoverTransport(httpClient.Transport, cfg.addresses, cfg.scheme, cfg.logger)
+		httpClient.Transport = failover
+	}
+	if cfg.faults != nil {
+		httpClient.Transport = newFaultTransport(httpClient.Transport, *cfg.faults)
+	}
+	if cfg.retry != nil {
+		// 重试层在故障注入之外，便于用注入的故障验证重试
+		httpClient.Transport = newRetryTransport(httpClient.Transport, *cfg.retry)
+	}
+	var login *loginSession
+	if cfg.login != nil {
+		login = &loginSession{opts: *cfg.login}
+		httpClient.Transport = &loginTransport{base: httpClient.Transport, session: login}
+	}
+	config.HttpClient = httpClient
 
 	// 创建Consul客户端
 	client, err := api.NewClient(config)
@@ -146,30 +298,80 @@ func NewClient(opts ...Option) (*Client, error) {
 		cancel() // 如果出错，取消上下文
 		return nil, fmt.Errorf("failed to create consul client: %v", err)
 	}
+	if len(cfg.headers) > 0 {
+		client.SetHeaders(cfg.headers.Clone())
+	}
 
-	// 测试连接（带重试机制）
+	// 测试连接（带指数退避重试）
+	backoff := cfg.backoffPolicy(0)
 	var lastErr error
+	connected := false
 	for i := 0; i <= cfg.maxRetries; i++ {
-		if _, _, err := client.Health().State("any", nil); err == nil {
-			// 连接成功
-			return &Client{
-				client: client,
-				logger: cfg.logger,
-				config: cfg,
-				ctx:    ctx,
-				cancel: cancel,
-			}, nil
-		} else {
-			lastErr = err
-			if i < cfg.maxRetries {
-				cfg.logger.Printf("Failed to connect to consul (attempt %d/%d): %v", i+1, cfg.maxRetries, err)
-				time.Sleep(cfg.retryTime)
-			}
+		_, _, err := client.Health().State("any", nil)
+		if err == nil {
+			connected = true
+			break
 		}
+		lastErr = err
+		if i < cfg.maxRetries {
+			delay := backoff.Delay(i)
+			cfg.logger.Printf("Failed to connect to consul (attempt %d/%d), retrying in %v: %v", i+1, cfg.maxRetries, delay, err)
+			time.Sleep(delay)
+		}
+	}
+	if !connected {
+		if cfg.localCache == "" {
+			cancel() // 如果连接失败，取消上下文
+			return nil, fmt.Errorf("failed to connect to consul after %d attempts: %v", cfg.maxRetries, lastErr)
+		}
+		// 启用了本地缓存时仍返回客户端，监听配置从缓存加载，请求在Consul恢复后自动成功
+		cfg.logger.Printf("Failed to connect to consul after %d attempts, starting with local cache %s: %v", cfg.maxRetries, cfg.localCache, lastErr)
 	}
 
-	cancel() // 如果连接失败，取消上下文
-	return nil, fmt.Errorf("failed to connect to consul after %d attempts: %v", cfg.maxRetries, lastErr)
+	c := &Client{
+		client:     client,
+		logger:     cfg.logger,
+		config:     cfg,
+		ctx:        ctx,
+		cancel:     cancel,
+		failover:   failover,
+		cacheStats: cacheStats,
+		services:   make(map[string]*ServiceConfig),
+		watches:    make(map[string]*watchState),
+		login:      login,
+	}
+	if login != nil {
+		login.renew = c.renewExpired
+		if connected {
+			token, err := c.doLogin()
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			login.swap(token)
+			c.goWorker("login renewal", c.runLoginRenewal)
+		} else {
+			c.goWorker("login renewal", c.runInitialLogin)
+		}
+	}
+	if cfg.autoDeregister {
+		c.watchExitSignals()
+	}
+	if cfg.kvCache != nil {
+		c.kvCache = &kvCache{prefix: *cfg.kvCache, invalid: make(map[string]uint64)}
+		c.goWorker("kv cache "+*cfg.kvCache, func() {
+			c.runKVCache(c.kvCache)
+		})
+	}
+	if failover != nil {
+		failover.onSwitch = func(from, to string) {
+			c.emit(Event{Type: EventConsulReconnected, Address: to, Message: "switched from " + from})
+		}
+		c.goWorker("failover probe", func() {
+			failover.runFailback(ctx, cfg.probeInterval)
+		})
+	}
+	return c, nil
 }
 
 // Close 关闭客户端并清理资源
@@ -177,6 +379,8 @@ func (c *Client) Close() error {
 	if c.cancel != nil {
 		c.cancel()
 	}
+	c.closeTransports()
+	c.endLogin()
 	c.logger.Println("Consul client closed")
 	return nil
 }
@@ -0,0 +1,66 @@
+package consul
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+// Codec 定义服务调用的请求/响应编解码器
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)      // 序列化请求体
+	Unmarshal(data []byte, v interface{}) error // 反序列化响应体
+	ContentType() string                        // Content-Type与Accept请求头的值
+}
+
+var (
+	// JSONCodec 使用JSON编解码
+	JSONCodec Codec = jsonCodec{}
+	// XMLCodec 使用XML编解码
+	XMLCodec Codec = xmlCodec{}
+	// ProtobufCodec 使用Protocol Buffers编解码，请求体和响应体必须实现proto.Message
+	ProtobufCodec Codec = protobufCodec{}
+	// MsgpackCodec 使用MessagePack编解码
+	MsgpackCodec Codec = msgpackCodec{}
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+func (jsonCodec) ContentType() string                        { return "application/json" }
+
+type xmlCodec struct{}
+
+func (xmlCodec) Marshal(v interface{}) ([]byte, error)      { return xml.Marshal(v) }
+func (xmlCodec) Unmarshal(data []byte, v interface{}) error { return xml.Unmarshal(data, v) }
+func (xmlCodec) ContentType() string                        { return "application/xml" }
+
+type protobufCodec struct{}
+
+func (protobufCodec) Marshal(v interface{}) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("%T does not implement proto.Message", v)
+	}
+	return proto.Marshal(msg)
+}
+
+func (protobufCodec) Unmarshal(data []byte, v interface{}) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("%T does not implement proto.Message", v)
+	}
+	return proto.Unmarshal(data, msg)
+}
+
+func (protobufCodec) ContentType() string { return "application/x-protobuf" }
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error)      { return msgpack.Marshal(v) }
+func (msgpackCodec) Unmarshal(data []byte, v interface{}) error { return msgpack.Unmarshal(data, v) }
+func (msgpackCodec) ContentType() string                        { return "application/msgpack" }
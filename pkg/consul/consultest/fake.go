@@ -0,0 +1,97 @@
+// fake.go
+package consultest
+
+import (
+	"io"
+	"log"
+	"testing"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/stones-hub/taurus-pro-consul/pkg/consul"
+)
+
+// NewFakeClient 创建连接到内存测试服务器的客户端，默认不输出日志，
+// 客户端和服务器在测试结束时自动关闭。返回的TestServer可用于直接修改数据，
+// 以确定性地驱动配置监听（阻塞查询）和服务调用的行为
+func NewFakeClient(t testing.TB, opts ...consul.Option) (*consul.Client, *TestServer) {
+	t.Helper()
+	srv := StartTestServer(t)
+
+	opts = append([]consul.Option{
+		consul.WithAddress(srv.Addr),
+		consul.WithLogger(log.New(io.Discard, "", 0)),
+	}, opts...)
+	client, err := consul.NewClient(opts...)
+	if err != nil {
+		t.Fatalf("failed to create fake client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client, srv
+}
+
+// Index 返回当前的全局索引，每次数据变化递增
+func (s *TestServer) Index() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.index
+}
+
+// SetKV 直接写入键值并唤醒相关的阻塞查询，返回写入后的修改索引
+func (s *TestServer) SetKV(key string, value []byte) uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.setKey(key, value, 0).ModifyIndex
+}
+
+// KV 直接读取键值，键不存在时返回false
+func (s *TestServer) KV(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pair, ok := s.kv[key]
+	if !ok {
+		return nil, false
+	}
+	return pair.Value, true
+}
+
+// DeleteKV 直接删除键值并唤醒相关的阻塞查询
+func (s *TestServer) DeleteKV(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.kv[key]; ok {
+		delete(s.kv, key)
+		s.kvIndex = s.bump()
+	}
+}
+
+// AddService 直接注册服务实例，状态为passing，
+// 可将httptest.Server的地址注册为实例来测试服务调用
+func (s *TestServer) AddService(svc *api.AgentService) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copied := *svc
+	if copied.ID == "" {
+		copied.ID = copied.Service
+	}
+	if copied.Weights.Passing == 0 {
+		copied.Weights = api.AgentWeights{Passing: 1, Warning: 1}
+	}
+	copied.Datacenter = Datacenter
+	s.catalogIndex = s.bump()
+	copied.CreateIndex, copied.ModifyIndex = s.catalogIndex, s.catalogIndex
+	s.services[copied.ID] = &copied
+	s.health[copied.ID] = api.HealthPassing
+}
+
+// RemoveService 直接移除服务实例
+func (s *TestServer) RemoveService(serviceID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.services[serviceID]; !ok {
+		return
+	}
+	delete(s.services, serviceID)
+	delete(s.health, serviceID)
+	delete(s.maintenance, serviceID)
+	s.catalogIndex = s.bump()
+}
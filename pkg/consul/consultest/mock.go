@@ -0,0 +1,143 @@
+// mock.go
+package consultest
+
+import (
+	"errors"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/stones-hub/taurus-pro-consul/pkg/consul"
+)
+
+// ErrNotMocked 调用了未设置实现的MockClient方法
+var ErrNotMocked = errors.New("consultest: method not mocked")
+
+// MockClient 实现consul.API的模拟客户端，按需设置对应的Func字段，
+// 未设置的方法返回ErrNotMocked
+type MockClient struct {
+	PutFunc       func(key string, value []byte, opts ...consul.WriteOption) error
+	GetFunc       func(key string, opts ...consul.QueryOption) ([]byte, error)
+	DeleteFunc    func(key string, opts ...consul.WriteOption) error
+	ListFunc      func(prefix string, opts ...consul.QueryOption) (map[string][]byte, error)
+	CASFunc       func(key string, value []byte, version uint64, opts ...consul.WriteOption) (bool, error)
+	UpdateKeyFunc func(key string, fn consul.UpdateFunc, opts ...consul.WriteOption) error
+
+	RegisterServiceFunc   func(cfg *consul.ServiceConfig, opts ...consul.WriteOption) error
+	DeregisterServiceFunc func(serviceID string, opts ...consul.QueryOption) error
+
+	GetHealthChecksFunc    func(serviceID string, opts ...consul.QueryOption) (api.HealthChecks, error)
+	GetHealthyServicesFunc func(name string, opts ...consul.QueryOption) ([]*api.ServiceEntry, error)
+
+	GetServiceFunc     func(name string, tag string, opts ...consul.QueryOption) ([]*api.ServiceEntry, error)
+	GetAllServicesFunc func(opts ...consul.QueryOption) (map[string][]string, error)
+	InstancesFunc      func(name string, tags []string, opts ...consul.QueryOption) ([]consul.Instance, error)
+
+	WatchConfigFunc    func(key string, config interface{}, opts *consul.WatchOptions) error
+	WatchConfigSetFunc func(targets map[string]interface{}, onChange consul.ConfigSetHandler, opts *consul.WatchOptions) error
+}
+
+var _ consul.API = (*MockClient)(nil)
+
+func (m *MockClient) Put(key string, value []byte, opts ...consul.WriteOption) error {
+	if m.PutFunc == nil {
+		return ErrNotMocked
+	}
+	return m.PutFunc(key, value, opts...)
+}
+
+func (m *MockClient) Get(key string, opts ...consul.QueryOption) ([]byte, error) {
+	if m.GetFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return m.GetFunc(key, opts...)
+}
+
+func (m *MockClient) Delete(key string, opts ...consul.WriteOption) error {
+	if m.DeleteFunc == nil {
+		return ErrNotMocked
+	}
+	return m.DeleteFunc(key, opts...)
+}
+
+func (m *MockClient) List(prefix string, opts ...consul.QueryOption) (map[string][]byte, error) {
+	if m.ListFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return m.ListFunc(prefix, opts...)
+}
+
+func (m *MockClient) CAS(key string, value []byte, version uint64, opts ...consul.WriteOption) (bool, error) {
+	if m.CASFunc == nil {
+		return false, ErrNotMocked
+	}
+	return m.CASFunc(key, value, version, opts...)
+}
+
+func (m *MockClient) UpdateKey(key string, fn consul.UpdateFunc, opts ...consul.WriteOption) error {
+	if m.UpdateKeyFunc == nil {
+		return ErrNotMocked
+	}
+	return m.UpdateKeyFunc(key, fn, opts...)
+}
+
+func (m *MockClient) RegisterService(cfg *consul.ServiceConfig, opts ...consul.WriteOption) error {
+	if m.RegisterServiceFunc == nil {
+		return ErrNotMocked
+	}
+	return m.RegisterServiceFunc(cfg, opts...)
+}
+
+func (m *MockClient) DeregisterService(serviceID string, opts ...consul.QueryOption) error {
+	if m.DeregisterServiceFunc == nil {
+		return ErrNotMocked
+	}
+	return m.DeregisterServiceFunc(serviceID, opts...)
+}
+
+func (m *MockClient) GetHealthChecks(serviceID string, opts ...consul.QueryOption) (api.HealthChecks, error) {
+	if m.GetHealthChecksFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return m.GetHealthChecksFunc(serviceID, opts...)
+}
+
+func (m *MockClient) GetHealthyServices(name string, opts ...consul.QueryOption) ([]*api.ServiceEntry, error) {
+	if m.GetHealthyServicesFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return m.GetHealthyServicesFunc(name, opts...)
+}
+
+func (m *MockClient) GetService(name string, tag string, opts ...consul.QueryOption) ([]*api.ServiceEntry, error) {
+	if m.GetServiceFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return m.GetServiceFunc(name, tag, opts...)
+}
+
+func (m *MockClient) GetAllServices(opts ...consul.QueryOption) (map[string][]string, error) {
+	if m.GetAllServicesFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return m.GetAllServicesFunc(opts...)
+}
+
+func (m *MockClient) Instances(name string, tags []string, opts ...consul.QueryOption) ([]consul.Instance, error) {
+	if m.InstancesFunc == nil {
+		return nil, ErrNotMocked
+	}
+	return m.InstancesFunc(name, tags, opts...)
+}
+
+func (m *MockClient) WatchConfig(key string, config interface{}, opts *consul.WatchOptions) error {
+	if m.WatchConfigFunc == nil {
+		return ErrNotMocked
+	}
+	return m.WatchConfigFunc(key, config, opts)
+}
+
+func (m *MockClient) WatchConfigSet(targets map[string]interface{}, onChange consul.ConfigSetHandler, opts *consul.WatchOptions) error {
+	if m.WatchConfigSetFunc == nil {
+		return ErrNotMocked
+	}
+	return m.WatchConfigSetFunc(targets, onChange, opts)
+}
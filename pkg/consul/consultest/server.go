@@ -0,0 +1,731 @@
+// Package consultest 提供内存实现的Consul测试服务器，
+// 无需运行真实的Consul即可对服务注册、KV和配置监听逻辑进行单元测试
+package consultest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+)
+
+const (
+	// NodeName 测试服务器的节点名
+	NodeName = "consultest"
+	// Datacenter 测试服务器的数据中心
+	Datacenter = "dc1"
+
+	maxWait = 10 * time.Minute
+)
+
+// TestServer 内存实现的Consul HTTP服务器，支持本包用到的API子集：
+// 服务注册与注销、健康检查注册、维护模式、目录与健康查询、KV（含CAS、会话锁和阻塞查询）、KV事务、会话。
+// 不支持过滤表达式，携带filter参数的请求返回400
+type TestServer struct {
+	// Addr 服务器地址，可直接传给consul.WithAddress
+	Addr string
+
+	srv *httptest.Server
+
+	mu           sync.Mutex
+	index        uint64                       // 全局Raft索引
+	kvIndex      uint64                       // KV表最近一次修改的索引
+	catalogIndex uint64                       // 服务目录最近一次修改的索引
+	kv           map[string]*api.KVPair       // KV存储
+	services     map[string]*api.AgentService // 已注册的服务
+	health       map[string]string            // 服务健康状态
+	maintenance  map[string]bool              // 处于维护模式的服务
+	checks       map[string]*api.AgentCheck   // 单独注册的健康检查
+	sessions     map[string]*session          // 会话
+	changed      chan struct{}                // 数据变化时关闭，用于唤醒阻塞查询
+}
+
+// NewTestServer 启动内存Consul测试服务器，使用完毕后需调用Close
+func NewTestServer() *TestServer {
+	s := &TestServer{
+		kv:          make(map[string]*api.KVPair),
+		services:    make(map[string]*api.AgentService),
+		health:      make(map[string]string),
+		maintenance: make(map[string]bool),
+		checks:      make(map[string]*api.AgentCheck),
+		sessions:    make(map[string]*session),
+		changed:     make(chan struct{}),
+	}
+	s.srv = httptest.NewServer(s.handler())
+	s.Addr = strings.TrimPrefix(s.srv.URL, "http://")
+	return s
+}
+
+// StartTestServer 启动内存Consul测试服务器，并在测试结束时自动关闭
+func StartTestServer(t testing.TB) *TestServer {
+	t.Helper()
+	s := NewTestServer()
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Close 关闭测试服务器
+func (s *TestServer) Close() {
+	s.srv.CloseClientConnections()
+	s.srv.Close()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sess := range s.sessions {
+		if sess.timer != nil {
+			sess.timer.Stop()
+		}
+	}
+}
+
+// SetServiceHealth 设置服务实例的健康状态（passing、warning、critical），
+// 注册的服务默认为passing
+func (s *TestServer) SetServiceHealth(serviceID, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.services[serviceID]; !ok {
+		return
+	}
+	s.health[serviceID] = status
+	s.catalogIndex = s.bump()
+}
+
+// bump 递增全局索引并唤醒阻塞查询，调用方需持有锁
+func (s *TestServer) bump() uint64 {
+	s.index++
+	close(s.changed)
+	s.changed = make(chan struct{})
+	return s.index
+}
+
+// handler 返回测试服务器的路由
+func (s *TestServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/status/leader", s.handleLeader)
+	mux.HandleFunc("/v1/agent/self", s.handleAgentSelf)
+	mux.HandleFunc("/v1/agent/services", s.handleAgentServices)
+	mux.HandleFunc("/v1/agent/checks", s.handleAgentChecks)
+	mux.HandleFunc("/v1/agent/service/", s.handleAgentService)
+	mux.HandleFunc("/v1/agent/service/register", s.handleRegister)
+	mux.HandleFunc("/v1/agent/check/register", s.handleCheckRegister)
+	mux.HandleFunc("/v1/agent/service/deregister/", s.handleDeregister)
+	mux.HandleFunc("/v1/agent/service/maintenance/", s.handleMaintenance)
+	mux.HandleFunc("/v1/catalog/datacenters", s.handleCatalogDatacenters)
+	mux.HandleFunc("/v1/catalog/services", s.handleCatalogServices)
+	mux.HandleFunc("/v1/catalog/service/", s.handleCatalogService)
+	mux.HandleFunc("/v1/health/service/", s.handleHealthService)
+	mux.HandleFunc("/v1/health/state/", s.handleHealthState)
+	mux.HandleFunc("/v1/kv/", s.handleKV)
+	mux.HandleFunc("/v1/txn", s.handleTxn)
+	mux.HandleFunc("/v1/session/create", s.handleSessionCreate)
+	mux.HandleFunc("/v1/session/destroy/", s.handleSessionDestroy)
+	mux.HandleFunc("/v1/session/renew/", s.handleSessionRenew)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("filter") != "" {
+			http.Error(w, "filter expressions are not supported by consultest", http.StatusBadRequest)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// block 按index和wait参数等待数据变化，current返回资源当前的索引。
+// 返回时持有锁，调用方需负责解锁
+func (s *TestServer) block(r *http.Request, current func() uint64) {
+	q := r.URL.Query()
+	waitIndex, _ := strconv.ParseUint(q.Get("index"), 10, 64)
+	wait := 5 * time.Minute
+	if d, err := time.ParseDuration(q.Get("wait")); err == nil && d > 0 {
+		wait = min(d, maxWait)
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	s.mu.Lock()
+	for waitIndex > 0 && current() <= waitIndex {
+		changed := s.changed
+		s.mu.Unlock()
+		select {
+		case <-changed:
+		case <-timer.C:
+			s.mu.Lock()
+			return
+		case <-r.Context().Done():
+			s.mu.Lock()
+			return
+		}
+		s.mu.Lock()
+	}
+}
+
+// writeJSON 写入带索引头的JSON响应
+func writeJSON(w http.ResponseWriter, index uint64, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(max(index, 1), 10))
+	w.Header().Set("X-Consul-KnownLeader", "true")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func (s *TestServer) handleLeader(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, 0, http.StatusOK, "127.0.0.1:8300")
+}
+
+func (s *TestServer) handleCatalogDatacenters(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, 0, http.StatusOK, []string{Datacenter})
+}
+
+func (s *TestServer) handleAgentSelf(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, 0, http.StatusOK, map[string]map[string]interface{}{
+		"Config": {"NodeName": NodeName, "Datacenter": Datacenter},
+	})
+}
+
+func (s *TestServer) handleAgentServices(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeJSON(w, s.catalogIndex, http.StatusOK, s.services)
+}
+
+func (s *TestServer) handleAgentChecks(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	checks := make(map[string]*api.AgentCheck, len(s.services))
+	for id := range s.services {
+		check := s.serviceCheck(id)
+		checks[check.CheckID] = &api.AgentCheck{
+			Node:        check.Node,
+			CheckID:     check.CheckID,
+			Name:        check.Name,
+			Status:      check.Status,
+			ServiceID:   check.ServiceID,
+			ServiceName: check.ServiceName,
+		}
+	}
+	for id, check := range s.checks {
+		checks[id] = check
+	}
+	writeJSON(w, s.catalogIndex, http.StatusOK, checks)
+}
+
+func (s *TestServer) handleAgentService(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/v1/agent/service/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	svc, ok := s.services[id]
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown service ID: %s", id), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, s.catalogIndex, http.StatusOK, svc)
+}
+
+func (s *TestServer) handleCheckRegister(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var reg api.AgentCheckRegistration
+	if err := json.NewDecoder(r.Body).Decode(&reg); err != nil {
+		http.Error(w, fmt.Sprintf("invalid check registration: %v", err), http.StatusBadRequest)
+		return
+	}
+	if reg.ID == "" {
+		reg.ID = reg.Name
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	check := &api.AgentCheck{
+		Node:      NodeName,
+		CheckID:   reg.ID,
+		Name:      reg.Name,
+		Status:    api.HealthCritical,
+		ServiceID: reg.ServiceID,
+	}
+	if reg.ServiceID != "" {
+		svc, ok := s.services[reg.ServiceID]
+		if !ok {
+			http.Error(w, fmt.Sprintf("ServiceID %q does not exist", reg.ServiceID), http.StatusBadRequest)
+			return
+		}
+		check.ServiceName = svc.Service
+	}
+	if reg.Status != "" {
+		check.Status = reg.Status
+	}
+	s.checks[reg.ID] = check
+	s.catalogIndex = s.bump()
+}
+
+func (s *TestServer) handleRegister(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var reg api.AgentServiceRegistration
+	if err := json.NewDecoder(r.Body).Decode(&reg); err != nil {
+		http.Error(w, fmt.Sprintf("invalid registration: %v", err), http.StatusBadRequest)
+		return
+	}
+	if reg.Name == "" {
+		http.Error(w, "missing service name", http.StatusBadRequest)
+		return
+	}
+	if reg.ID == "" {
+		reg.ID = reg.Name
+	}
+
+	svc := &api.AgentService{
+		ID:         reg.ID,
+		Service:    reg.Name,
+		Tags:       reg.Tags,
+		Meta:       reg.Meta,
+		Port:       reg.Port,
+		Address:    reg.Address,
+		Weights:    api.AgentWeights{Passing: 1, Warning: 1},
+		Datacenter: Datacenter,
+	}
+	if reg.Weights != nil {
+		svc.Weights = *reg.Weights
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.services[reg.ID] = svc
+	if _, ok := s.health[reg.ID]; !ok {
+		s.health[reg.ID] = api.HealthPassing
+	}
+	s.catalogIndex = s.bump()
+	svc.CreateIndex, svc.ModifyIndex = s.catalogIndex, s.catalogIndex
+}
+
+func (s *TestServer) handleDeregister(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/v1/agent/service/deregister/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.services[id]; !ok {
+		http.Error(w, fmt.Sprintf("Unknown service ID %q", id), http.StatusNotFound)
+		return
+	}
+	delete(s.services, id)
+	delete(s.health, id)
+	delete(s.maintenance, id)
+	for checkID, check := range s.checks {
+		if check.ServiceID == id {
+			delete(s.checks, checkID)
+		}
+	}
+	s.catalogIndex = s.bump()
+}
+
+func (s *TestServer) handleMaintenance(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/v1/agent/service/maintenance/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.services[id]; !ok {
+		http.Error(w, fmt.Sprintf("Unknown service ID %q", id), http.StatusNotFound)
+		return
+	}
+	s.maintenance[id] = r.URL.Query().Get("enable") == "true"
+	s.catalogIndex = s.bump()
+}
+
+func (s *TestServer) handleCatalogServices(w http.ResponseWriter, r *http.Request) {
+	s.block(r, func() uint64 { return s.catalogIndex })
+	defer s.mu.Unlock()
+
+	out := map[string][]string{"consul": {}}
+	for _, svc := range s.services {
+		for _, tag := range svc.Tags {
+			if !slices.Contains(out[svc.Service], tag) {
+				out[svc.Service] = append(out[svc.Service], tag)
+			}
+		}
+		if out[svc.Service] == nil {
+			out[svc.Service] = []string{}
+		}
+	}
+	writeJSON(w, s.catalogIndex, http.StatusOK, out)
+}
+
+func (s *TestServer) handleCatalogService(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/v1/catalog/service/")
+	tags := r.URL.Query()["tag"]
+
+	s.block(r, func() uint64 { return s.catalogIndex })
+	defer s.mu.Unlock()
+
+	out := []*api.CatalogService{}
+	for _, svc := range s.sortedServices(name, tags) {
+		out = append(out, &api.CatalogService{
+			Node:           NodeName,
+			Address:        "127.0.0.1",
+			Datacenter:     Datacenter,
+			ServiceID:      svc.ID,
+			ServiceName:    svc.Service,
+			ServiceAddress: svc.Address,
+			ServiceTags:    svc.Tags,
+			ServiceMeta:    svc.Meta,
+			ServicePort:    svc.Port,
+			ServiceWeights: api.Weights{Passing: svc.Weights.Passing, Warning: svc.Weights.Warning},
+			CreateIndex:    svc.CreateIndex,
+			ModifyIndex:    svc.ModifyIndex,
+		})
+	}
+	writeJSON(w, s.catalogIndex, http.StatusOK, out)
+}
+
+func (s *TestServer) handleHealthService(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/v1/health/service/")
+	tags := r.URL.Query()["tag"]
+	_, passingOnly := r.URL.Query()["passing"]
+
+	s.block(r, func() uint64 { return s.catalogIndex })
+	defer s.mu.Unlock()
+
+	out := []*api.ServiceEntry{}
+	for _, svc := range s.sortedServices(name, tags) {
+		check := s.serviceCheck(svc.ID)
+		if passingOnly && check.Status != api.HealthPassing {
+			continue
+		}
+		out = append(out, &api.ServiceEntry{
+			Node:    &api.Node{Node: NodeName, Address: "127.0.0.1", Datacenter: Datacenter},
+			Service: svc,
+			Checks:  api.HealthChecks{check},
+		})
+	}
+	writeJSON(w, s.catalogIndex, http.StatusOK, out)
+}
+
+func (s *TestServer) handleHealthState(w http.ResponseWriter, r *http.Request) {
+	state := strings.TrimPrefix(r.URL.Path, "/v1/health/state/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := api.HealthChecks{}
+	for _, svc := range s.sortedServices("", nil) {
+		check := s.serviceCheck(svc.ID)
+		if state == "any" || state == check.Status {
+			out = append(out, check)
+		}
+	}
+	writeJSON(w, s.catalogIndex, http.StatusOK, out)
+}
+
+// sortedServices 返回按ID排序、名称和标签匹配的服务，name为空时返回全部，调用方需持有锁
+func (s *TestServer) sortedServices(name string, tags []string) []*api.AgentService {
+	var out []*api.AgentService
+	for _, svc := range s.services {
+		if name != "" && svc.Service != name {
+			continue
+		}
+		if !containsAll(svc.Tags, tags) {
+			continue
+		}
+		out = append(out, svc)
+	}
+	slices.SortFunc(out, func(a, b *api.AgentService) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	return out
+}
+
+// serviceCheck 返回服务的健康检查，维护模式下为critical，调用方需持有锁
+func (s *TestServer) serviceCheck(id string) *api.HealthCheck {
+	svc := s.services[id]
+	status := s.health[id]
+	if s.maintenance[id] {
+		status = api.HealthCritical
+	}
+	return &api.HealthCheck{
+		Node:        NodeName,
+		CheckID:     "service:" + id,
+		Name:        "Service '" + svc.Service + "' check",
+		Status:      status,
+		ServiceID:   id,
+		ServiceName: svc.Service,
+		ServiceTags: svc.Tags,
+	}
+}
+
+func (s *TestServer) handleKV(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+	switch r.Method {
+	case http.MethodGet:
+		s.kvGet(w, r, key)
+	case http.MethodPut:
+		s.kvPut(w, r, key)
+	case http.MethodDelete:
+		s.kvDelete(w, r, key)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *TestServer) kvGet(w http.ResponseWriter, r *http.Request, key string) {
+	q := r.URL.Query()
+	_, recurse := q["recurse"]
+	_, keysOnly := q["keys"]
+
+	s.block(r, func() uint64 { return s.kvIndex })
+	defer s.mu.Unlock()
+
+	if !recurse && !keysOnly {
+		pair, ok := s.kv[key]
+		if !ok {
+			writeJSON(w, s.kvIndex, http.StatusNotFound, nil)
+			return
+		}
+		writeJSON(w, s.kvIndex, http.StatusOK, api.KVPairs{pair})
+		return
+	}
+
+	var pairs api.KVPairs
+	for k, pair := range s.kv {
+		if strings.HasPrefix(k, key) {
+			pairs = append(pairs, pair)
+		}
+	}
+	if len(pairs) == 0 {
+		writeJSON(w, s.kvIndex, http.StatusNotFound, nil)
+		return
+	}
+	slices.SortFunc(pairs, func(a, b *api.KVPair) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+
+	if keysOnly {
+		sep := q.Get("separator")
+		var keys []string
+		for _, pair := range pairs {
+			k := pair.Key
+			if sep != "" {
+				if i := strings.Index(k[len(key):], sep); i >= 0 {
+					k = k[:len(key)+i+len(sep)]
+				}
+			}
+			if len(keys) == 0 || keys[len(keys)-1] != k {
+				keys = append(keys, k)
+			}
+		}
+		writeJSON(w, s.kvIndex, http.StatusOK, keys)
+		return
+	}
+	writeJSON(w, s.kvIndex, http.StatusOK, pairs)
+}
+
+func (s *TestServer) kvPut(w http.ResponseWriter, r *http.Request, key string) {
+	value, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read body: %v", err), http.StatusBadRequest)
+		return
+	}
+	q := r.URL.Query()
+	flags, _ := strconv.ParseUint(q.Get("flags"), 10, 64)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if q.Has("cas") {
+		cas, _ := strconv.ParseUint(q.Get("cas"), 10, 64)
+		if !s.casMatches(key, cas) {
+			writeJSON(w, s.kvIndex, http.StatusOK, false)
+			return
+		}
+	}
+	switch {
+	case q.Has("acquire"):
+		writeJSON(w, s.kvIndex, http.StatusOK, s.acquireKey(key, q.Get("acquire"), value, flags))
+		return
+	case q.Has("release"):
+		writeJSON(w, s.kvIndex, http.StatusOK, s.releaseKey(key, q.Get("release"), value, flags))
+		return
+	}
+	s.setKey(key, value, flags)
+	writeJSON(w, s.kvIndex, http.StatusOK, true)
+}
+
+func (s *TestServer) kvDelete(w http.ResponseWriter, r *http.Request, key string) {
+	q := r.URL.Query()
+	_, recurse := q["recurse"]
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if q.Has("cas") {
+		cas, _ := strconv.ParseUint(q.Get("cas"), 10, 64)
+		if !s.casMatches(key, cas) {
+			writeJSON(w, s.kvIndex, http.StatusOK, false)
+			return
+		}
+	}
+
+	deleted := false
+	for k := range s.kv {
+		if k == key || (recurse && strings.HasPrefix(k, key)) {
+			delete(s.kv, k)
+			deleted = true
+		}
+	}
+	if deleted {
+		s.kvIndex = s.bump()
+	}
+	writeJSON(w, s.kvIndex, http.StatusOK, true)
+}
+
+// casMatches 检查CAS条件，cas为0表示键必须不存在，调用方需持有锁
+func (s *TestServer) casMatches(key string, cas uint64) bool {
+	pair, ok := s.kv[key]
+	if cas == 0 {
+		return !ok
+	}
+	return ok && pair.ModifyIndex == cas
+}
+
+// setKey 写入键值，调用方需持有锁
+func (s *TestServer) setKey(key string, value []byte, flags uint64) *api.KVPair {
+	s.kvIndex = s.bump()
+	pair, ok := s.kv[key]
+	if !ok {
+		pair = &api.KVPair{Key: key, CreateIndex: s.kvIndex}
+		s.kv[key] = pair
+	}
+	pair.Value = value
+	pair.Flags = flags
+	pair.ModifyIndex = s.kvIndex
+	return pair
+}
+
+func (s *TestServer) handleTxn(w http.ResponseWriter, r *http.Request) {
+	var ops api.TxnOps
+	if err := json.NewDecoder(r.Body).Decode(&ops); err != nil {
+		http.Error(w, fmt.Sprintf("invalid transaction: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 先校验全部操作，任一失败则整体回滚
+	var errs api.TxnErrors
+	for i, op := range ops {
+		if op.KV == nil {
+			errs = append(errs, &api.TxnError{OpIndex: i, What: "only KV operations are supported by consultest"})
+			continue
+		}
+		if what := s.checkTxnOp(op.KV); what != "" {
+			errs = append(errs, &api.TxnError{OpIndex: i, What: what})
+		}
+	}
+	if len(errs) > 0 {
+		writeJSON(w, s.kvIndex, http.StatusConflict, api.TxnResponse{Errors: errs})
+		return
+	}
+
+	var results api.TxnResults
+	for _, op := range ops {
+		if pair := s.applyTxnOp(op.KV); pair != nil {
+			results = append(results, &api.TxnResult{KV: pair})
+		}
+	}
+	writeJSON(w, s.kvIndex, http.StatusOK, api.TxnResponse{Results: results})
+}
+
+// checkTxnOp 校验事务操作的前置条件，返回失败原因，调用方需持有锁
+func (s *TestServer) checkTxnOp(op *api.KVTxnOp) string {
+	pair, exists := s.kv[op.Key]
+	switch op.Verb {
+	case api.KVSet, api.KVGetOrEmpty, api.KVDelete, api.KVDeleteTree:
+	case api.KVGet:
+		if !exists {
+			return fmt.Sprintf("key %q doesn't exist", op.Key)
+		}
+	case api.KVCAS, api.KVDeleteCAS:
+		if !s.casMatches(op.Key, op.Index) {
+			return fmt.Sprintf("failed to set key %q, index is stale", op.Key)
+		}
+	case api.KVCheckIndex:
+		if !exists || pair.ModifyIndex != op.Index {
+			return fmt.Sprintf("current modify index %d for key %q does not match", op.Index, op.Key)
+		}
+	case api.KVCheckNotExists:
+		if exists {
+			return fmt.Sprintf("key %q exists", op.Key)
+		}
+	case api.KVLock:
+		if _, ok := s.sessions[op.Session]; !ok {
+			return fmt.Sprintf("invalid session %q", op.Session)
+		}
+		if exists && pair.Session != "" && pair.Session != op.Session {
+			return fmt.Sprintf("failed to lock key %q, lock is already held", op.Key)
+		}
+	default:
+		return fmt.Sprintf("unsupported KV verb %q", op.Verb)
+	}
+	return ""
+}
+
+// applyTxnOp 执行已校验的事务操作，返回需要写入结果的键值，调用方需持有锁
+func (s *TestServer) applyTxnOp(op *api.KVTxnOp) *api.KVPair {
+	switch op.Verb {
+	case api.KVSet, api.KVCAS:
+		pair := *s.setKey(op.Key, op.Value, op.Flags)
+		pair.Value = nil
+		return &pair
+	case api.KVLock:
+		s.acquireKey(op.Key, op.Session, op.Value, op.Flags)
+		pair := *s.kv[op.Key]
+		pair.Value = nil
+		return &pair
+	case api.KVGet:
+		pair := *s.kv[op.Key]
+		return &pair
+	case api.KVGetOrEmpty:
+		if pair, ok := s.kv[op.Key]; ok {
+			p := *pair
+			return &p
+		}
+		return &api.KVPair{Key: op.Key}
+	case api.KVDelete, api.KVDeleteCAS:
+		if _, ok := s.kv[op.Key]; ok {
+			delete(s.kv, op.Key)
+			s.kvIndex = s.bump()
+		}
+	case api.KVDeleteTree:
+		deleted := false
+		for k := range s.kv {
+			if strings.HasPrefix(k, op.Key) {
+				delete(s.kv, k)
+				deleted = true
+			}
+		}
+		if deleted {
+			s.kvIndex = s.bump()
+		}
+	}
+	return nil
+}
+
+// containsAll 检查tags是否包含全部want
+func containsAll(tags, want []string) bool {
+	for _, t := range want {
+		if !slices.Contains(tags, t) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,158 @@
+package consultest
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// session 测试服务器中的会话
+type session struct {
+	entry *api.SessionEntry
+	timer *time.Timer // TTL到期时使会话失效，未设置TTL时为nil
+}
+
+func (s *TestServer) handleSessionCreate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	// api.Session().Create将LockDelay编码为字符串
+	var req struct {
+		Name      string
+		Behavior  string
+		TTL       string
+		LockDelay string
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("invalid session: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+	entry := api.SessionEntry{Name: req.Name, Behavior: req.Behavior, TTL: req.TTL}
+	entry.LockDelay, _ = time.ParseDuration(req.LockDelay)
+	if entry.Behavior == "" {
+		entry.Behavior = api.SessionBehaviorRelease
+	}
+	entry.ID = newUUID()
+	entry.Node = NodeName
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess := &session{entry: &entry}
+	s.sessions[entry.ID] = sess
+	s.resetSessionTTL(sess)
+	entry.CreateIndex = s.bump()
+	writeJSON(w, s.index, http.StatusOK, map[string]string{"ID": entry.ID})
+}
+
+func (s *TestServer) handleSessionDestroy(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/v1/session/destroy/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.invalidateSession(id)
+	writeJSON(w, s.index, http.StatusOK, true)
+}
+
+func (s *TestServer) handleSessionRenew(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/v1/session/renew/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, ok := s.sessions[id]
+	if !ok {
+		http.Error(w, fmt.Sprintf("Session id '%s' not found", id), http.StatusNotFound)
+		return
+	}
+	s.resetSessionTTL(sess)
+	writeJSON(w, s.index, http.StatusOK, []*api.SessionEntry{sess.entry})
+}
+
+// resetSessionTTL 重新开始会话的TTL计时，调用方需持有锁
+func (s *TestServer) resetSessionTTL(sess *session) {
+	ttl, _ := time.ParseDuration(sess.entry.TTL)
+	if ttl <= 0 {
+		return
+	}
+	if sess.timer != nil {
+		sess.timer.Stop()
+	}
+	id := sess.entry.ID
+	sess.timer = time.AfterFunc(ttl, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.invalidateSession(id)
+	})
+}
+
+// invalidateSession 使会话失效，按会话行为释放或删除其持有的键，调用方需持有锁
+func (s *TestServer) invalidateSession(id string) {
+	sess, ok := s.sessions[id]
+	if !ok {
+		return
+	}
+	if sess.timer != nil {
+		sess.timer.Stop()
+	}
+	delete(s.sessions, id)
+
+	var released []*api.KVPair
+	for key, pair := range s.kv {
+		if pair.Session != id {
+			continue
+		}
+		if sess.entry.Behavior == api.SessionBehaviorDelete {
+			delete(s.kv, key)
+		} else {
+			pair.Session = ""
+		}
+		released = append(released, pair)
+	}
+	index := s.bump()
+	if len(released) > 0 {
+		s.kvIndex = index
+		for _, pair := range released {
+			pair.ModifyIndex = index
+		}
+	}
+}
+
+// acquireKey 以会话锁定并写入键，键已被其他会话持有时返回false，调用方需持有锁
+func (s *TestServer) acquireKey(key, id string, value []byte, flags uint64) bool {
+	if _, ok := s.sessions[id]; !ok {
+		return false
+	}
+	if pair, ok := s.kv[key]; ok && pair.Session != "" && pair.Session != id {
+		return false
+	}
+	pair := s.setKey(key, value, flags)
+	if pair.Session != id {
+		pair.LockIndex++
+	}
+	pair.Session = id
+	return true
+}
+
+// releaseKey 释放会话持有的键并写入新值，键未被该会话持有时返回false，调用方需持有锁
+func (s *TestServer) releaseKey(key, id string, value []byte, flags uint64) bool {
+	if pair, ok := s.kv[key]; !ok || pair.Session != id {
+		return false
+	}
+	pair := s.setKey(key, value, flags)
+	pair.Session = ""
+	return true
+}
+
+// newUUID 生成随机的会话ID
+func newUUID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
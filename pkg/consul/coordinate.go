@@ -0,0 +1,167 @@
+package consul
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/serf/coordinate"
+)
+
+// coordinateCacheTTL RTT和NearestN使用的节点坐标缓存时间，坐标变化缓慢，
+// 缓存避免NearestFirst策略每次调用都查询全部节点坐标
+const coordinateCacheTTL = 10 * time.Second
+
+// coordinateCache 节点坐标和本地节点名的缓存，由c.mu保护
+type coordinateCache struct {
+	coords    map[string]*coordinate.Coordinate
+	fetched   time.Time
+	localNode string
+}
+
+// CoordinateNodes 获取当前数据中心所有节点的网络坐标
+func (c *Client) CoordinateNodes() ([]*api.CoordinateEntry, error) {
+	entries, _, err := c.client.Coordinate().Nodes(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node coordinates: %v", err)
+	}
+	return entries, nil
+}
+
+// CoordinateDatacenters 获取WAN中各数据中心服务器的网络坐标
+func (c *Client) CoordinateDatacenters() ([]*api.CoordinateDatacenterMap, error) {
+	dcs, err := c.client.Coordinate().Datacenters()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get datacenter coordinates: %v", err)
+	}
+	return dcs, nil
+}
+
+// RTT 估算两个节点之间的网络往返时间，节点坐标缓存10秒
+func (c *Client) RTT(nodeA, nodeB string) (time.Duration, error) {
+	coords, err := c.nodeCoordinates()
+	if err != nil {
+		return 0, err
+	}
+
+	a, ok := coords[nodeA]
+	if !ok {
+		return 0, fmt.Errorf("no coordinate found for node %s", nodeA)
+	}
+	b, ok := coords[nodeB]
+	if !ok {
+		return 0, fmt.Errorf("no coordinate found for node %s", nodeB)
+	}
+	if !a.IsCompatibleWith(b) {
+		return 0, fmt.Errorf("coordinates of node %s and %s are not compatible", nodeA, nodeB)
+	}
+	return a.DistanceTo(b), nil
+}
+
+// NearestN 按距本地节点的估算RTT升序排列服务实例并返回前n个，n<=0时返回全部，
+// 没有坐标信息的实例排在最后。节点坐标缓存10秒
+func (c *Client) NearestN(services []*api.ServiceEntry, n int) ([]*api.ServiceEntry, error) {
+	local, err := c.localNodeName()
+	if err != nil {
+		return nil, err
+	}
+
+	coords, err := c.nodeCoordinates()
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := make([]*api.ServiceEntry, len(services))
+	copy(sorted, services)
+
+	origin, ok := coords[local]
+	if ok {
+		rtt := func(entry *api.ServiceEntry) (time.Duration, bool) {
+			if entry.Node == nil {
+				return 0, false
+			}
+			coord, ok := coords[entry.Node.Node]
+			if !ok || !origin.IsCompatibleWith(coord) {
+				return 0, false
+			}
+			return origin.DistanceTo(coord), true
+		}
+
+		sort.SliceStable(sorted, func(i, j int) bool {
+			di, oki := rtt(sorted[i])
+			dj, okj := rtt(sorted[j])
+			if oki != okj {
+				return oki
+			}
+			return di < dj
+		})
+	}
+
+	if n > 0 && n < len(sorted) {
+		sorted = sorted[:n]
+	}
+	return sorted, nil
+}
+
+// localNodeName 返回本地Agent的节点名，首次获取后缓存
+func (c *Client) localNodeName() (string, error) {
+	c.mu.RLock()
+	local := c.coordCache.localNode
+	c.mu.RUnlock()
+	if local != "" {
+		return local, nil
+	}
+
+	local, err := c.client.Agent().NodeName()
+	if err != nil {
+		return "", fmt.Errorf("failed to get local node name: %v", err)
+	}
+	c.mu.Lock()
+	c.coordCache.localNode = local
+	c.mu.Unlock()
+	return local, nil
+}
+
+// nodeCoordinates 获取节点名到网络坐标的映射，缓存未过期时直接返回，并发的刷新合并为一次查询
+func (c *Client) nodeCoordinates() (map[string]*coordinate.Coordinate, error) {
+	c.mu.RLock()
+	coords, fetched := c.coordCache.coords, c.coordCache.fetched
+	c.mu.RUnlock()
+	if coords != nil && time.Since(fetched) < coordinateCacheTTL {
+		return coords, nil
+	}
+
+	v, err, _ := c.lookups.Do("coordinates", func() (interface{}, error) {
+		return c.fetchNodeCoordinates()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(map[string]*coordinate.Coordinate), nil
+}
+
+// fetchNodeCoordinates 查询全部节点坐标并更新缓存
+func (c *Client) fetchNodeCoordinates() (map[string]*coordinate.Coordinate, error) {
+	entries, err := c.CoordinateNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	coords := make(map[string]*coordinate.Coordinate, len(entries))
+	for _, entry := range entries {
+		if entry.Coord == nil {
+			continue
+		}
+		// 多个网络分段时只取第一个坐标
+		if _, exists := coords[entry.Node]; !exists {
+			coords[entry.Node] = entry.Coord
+		}
+	}
+
+	c.mu.Lock()
+	c.coordCache.coords = coords
+	c.coordCache.fetched = time.Now()
+	c.mu.Unlock()
+	return coords, nil
+}
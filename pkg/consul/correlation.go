@@ -0,0 +1,110 @@
+package consul
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// RequestIDHeader 是请求ID的请求头名称
+const RequestIDHeader = "X-Request-ID"
+
+// correlationKey 是上下文中关联请求头的键
+type correlationKey struct{}
+
+// WithCorrelationHeaders 设置需要从上下文透传到下游的关联请求头（X-Request-ID总是透传），
+// 上下文通过WithCallContext传入，通常来自CorrelationMiddleware处理过的入站请求
+func WithCorrelationHeaders(headers []string) InvokerOption {
+	return func(i *ServiceInvoker) {
+		for _, h := range headers {
+			i.correlationHeaders = append(i.correlationHeaders, http.CanonicalHeaderKey(h))
+		}
+	}
+}
+
+// ContextWithCorrelation 将关联请求头保存到上下文中
+func ContextWithCorrelation(ctx context.Context, headers map[string]string) context.Context {
+	values := make(map[string]string, len(headers))
+	for k, v := range CorrelationFromContext(ctx) {
+		values[k] = v
+	}
+	for k, v := range headers {
+		values[http.CanonicalHeaderKey(k)] = v
+	}
+	return context.WithValue(ctx, correlationKey{}, values)
+}
+
+// CorrelationFromContext 获取上下文中的关联请求头
+func CorrelationFromContext(ctx context.Context) map[string]string {
+	if ctx == nil {
+		return nil
+	}
+	values, _ := ctx.Value(correlationKey{}).(map[string]string)
+	return values
+}
+
+// RequestIDFromContext 获取上下文中的请求ID
+func RequestIDFromContext(ctx context.Context) string {
+	return CorrelationFromContext(ctx)[RequestIDHeader]
+}
+
+// CorrelationMiddleware 将入站请求的X-Request-ID和指定的关联请求头保存到请求上下文中，
+// 缺少X-Request-ID时自动生成，并在响应头中返回
+func CorrelationMiddleware(next http.Handler, headers ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		values := make(map[string]string, len(headers)+1)
+		for _, h := range headers {
+			if v := r.Header.Get(h); v != "" {
+				values[http.CanonicalHeaderKey(h)] = v
+			}
+		}
+
+		requestID := r.Header.Get(RequestIDHeader)
+		if requestID == "" {
+			requestID = NewRequestID()
+		}
+		values[RequestIDHeader] = requestID
+		w.Header().Set(RequestIDHeader, requestID)
+
+		next.ServeHTTP(w, r.WithContext(ContextWithCorrelation(r.Context(), values)))
+	})
+}
+
+// NewRequestID 生成随机的请求ID
+func NewRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// withCorrelation 返回补全了关联请求头的请求头副本（键已规范化），缺少请求ID时自动生成，
+// contentType不为空时同时设置Content-Type与Accept
+func (i *ServiceInvoker) withCorrelation(headers map[string]string, ctx context.Context, contentType string) map[string]string {
+	h := make(map[string]string, len(headers)+len(i.correlationHeaders)+3)
+	for k, v := range headers {
+		h[http.CanonicalHeaderKey(k)] = v
+	}
+	if contentType != "" {
+		h["Content-Type"] = contentType
+		h["Accept"] = contentType
+	}
+
+	values := CorrelationFromContext(ctx)
+	if _, ok := h[RequestIDHeader]; !ok {
+		if v, ok := values[RequestIDHeader]; ok {
+			h[RequestIDHeader] = v
+		} else {
+			h[RequestIDHeader] = NewRequestID()
+		}
+	}
+	for _, name := range i.correlationHeaders {
+		if _, ok := h[name]; ok {
+			continue
+		}
+		if v, ok := values[name]; ok {
+			h[name] = v
+		}
+	}
+	return h
+}
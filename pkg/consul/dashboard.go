@@ -0,0 +1,149 @@
+package consul
+
+import (
+	"encoding/json"
+	"html/template"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Dashboard 是健康面板展示的数据
+type Dashboard struct {
+	GeneratedAt time.Time           `json:"generated_at"`    // 生成时间
+	Error       string              `json:"error,omitempty"` // 查询Consul时的错误
+	Services    []*DashboardService `json:"services"`        // 所有服务
+	Watches     []WatchStatus       `json:"watches"`         // 本客户端监听的配置键
+}
+
+// DashboardService 是健康面板中的服务
+type DashboardService struct {
+	Name      string               `json:"name"`      // 服务名称
+	Passing   int                  `json:"passing"`   // 健康实例数
+	Instances []*DashboardInstance `json:"instances"` // 实例列表
+}
+
+// DashboardInstance 是健康面板中的服务实例
+type DashboardInstance struct {
+	ID      string        `json:"id"`      // 实例ID
+	Node    string        `json:"node"`    // 所在节点
+	Address string        `json:"address"` // 实例地址
+	Port    int           `json:"port"`    // 实例端口
+	Tags    []string      `json:"tags"`    // 实例标签
+	Status  string        `json:"status"`  // 聚合后的健康状态
+	Checks  []*LocalCheck `json:"checks"`  // 健康检查
+}
+
+// Dashboard 收集所有服务、实例、检查状态以及本客户端监听的配置键，监听状态取自内存，不额外访问Consul
+func (c *Client) Dashboard() *Dashboard {
+	d := &Dashboard{
+		GeneratedAt: time.Now(),
+		Watches:     c.WatchStatus(),
+	}
+
+	services, err := c.GetAllServices()
+	if err != nil {
+		d.Error = err.Error()
+		return d
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(services)) {
+		entries, _, err := c.client.Health().Service(name, "", false, c.queryOptions())
+		if err != nil {
+			d.Error = err.Error()
+			continue
+		}
+
+		svc := &DashboardService{Name: name}
+		for _, entry := range entries {
+			inst := &DashboardInstance{
+				ID:      entry.Service.ID,
+				Address: entry.Service.Address,
+				Port:    entry.Service.Port,
+				Tags:    entry.Service.Tags,
+				Status:  entry.Checks.AggregatedStatus(),
+			}
+			if entry.Node != nil {
+				inst.Node = entry.Node.Node
+				if inst.Address == "" {
+					inst.Address = entry.Node.Address
+				}
+			}
+			for _, check := range entry.Checks {
+				inst.Checks = append(inst.Checks, &LocalCheck{
+					ID:     check.CheckID,
+					Name:   check.Name,
+					Type:   check.Type,
+					Status: check.Status,
+					Output: check.Output,
+				})
+			}
+			if inst.Status == "passing" {
+				svc.Passing++
+			}
+			svc.Instances = append(svc.Instances, inst)
+		}
+		d.Services = append(d.Services, svc)
+	}
+
+	return d
+}
+
+// DashboardHandler 返回可嵌入的健康面板http.Handler，
+// 默认渲染HTML，请求带format=json参数或Accept为application/json时返回JSON
+func DashboardHandler(client *Client) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		d := client.Dashboard()
+
+		if r.URL.Query().Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json") {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(d)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := dashboardTemplate.Execute(w, d); err != nil {
+			client.logger.Printf("Failed to render dashboard: %v", err)
+		}
+	})
+}
+
+var dashboardTemplate = template.Must(template.New("dashboard").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Consul Dashboard</title>
+<style>
+body { font-family: sans-serif; margin: 20px; }
+table { border-collapse: collapse; margin-bottom: 20px; width: 100%; }
+th, td { border: 1px solid #ddd; padding: 4px 8px; text-align: left; }
+.passing { color: #2e7d32; } .warning { color: #f9a825; } .critical { color: #c62828; }
+</style>
+</head>
+<body>
+<h1>Consul Dashboard</h1>
+<p>Generated at {{.GeneratedAt.Format "2006-01-02 15:04:05"}}</p>
+{{if .Error}}<p class="critical">{{.Error}}</p>{{end}}
+{{range .Services}}
+<h2>{{.Name}} ({{.Passing}}/{{len .Instances}} passing)</h2>
+<table>
+<tr><th>ID</th><th>Node</th><th>Address</th><th>Tags</th><th>Status</th><th>Checks</th></tr>
+{{range .Instances}}
+<tr>
+<td>{{.ID}}</td><td>{{.Node}}</td><td>{{.Address}}:{{.Port}}</td><td>{{range .Tags}}{{.}} {{end}}</td>
+<td class="{{.Status}}">{{.Status}}</td>
+<td>{{range .Checks}}<div class="{{.Status}}" title="{{.Output}}">{{.Name}}: {{.Status}}</div>{{end}}</td>
+</tr>
+{{end}}
+</table>
+{{end}}
+<h2>Watched Keys</h2>
+<table>
+<tr><th>Key</th><th>Started</th></tr>
+{{range .Watches}}<tr><td>{{.Key}}</td><td>{{.Started.Format "2006-01-02 15:04:05"}}</td></tr>{{end}}
+</table>
+</body>
+</html>
+`))
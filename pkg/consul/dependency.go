@@ -0,0 +1,146 @@
+package consul
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DependencyStatus 描述单个依赖的就绪状态
+type DependencyStatus struct {
+	Kind      string    `json:"kind"`            // service 或 key
+	Name      string    `json:"name"`            // 服务名称或KV键
+	Ready     bool      `json:"ready"`           // 是否就绪
+	Error     string    `json:"error,omitempty"` // 未就绪的原因
+	CheckedAt time.Time `json:"checked_at"`      // 检查时间
+}
+
+// DependencyChecker 检查服务启动前必须存在于Consul中的依赖（健康的服务实例和KV键）
+type DependencyChecker struct {
+	client   *Client
+	interval time.Duration
+
+	mu       sync.Mutex
+	services map[string]int // 服务名称到最少健康实例数
+	keys     []string
+	last     []*DependencyStatus
+}
+
+// NewDependencyChecker 创建依赖检查器，interval为WaitAll的轮询间隔
+func (c *Client) NewDependencyChecker(interval time.Duration) *DependencyChecker {
+	if interval <= 0 {
+		interval = time.Second * 2
+	}
+	return &DependencyChecker{
+		client:   c,
+		interval: interval,
+		services: make(map[string]int),
+	}
+}
+
+// RequireService 声明依赖的服务，至少需要minInstances个健康实例（小于1时按1处理）
+func (d *DependencyChecker) RequireService(name string, minInstances int) *DependencyChecker {
+	if minInstances < 1 {
+		minInstances = 1
+	}
+	d.mu.Lock()
+	d.services[name] = minInstances
+	d.mu.Unlock()
+	return d
+}
+
+// RequireKey 声明依赖的KV键
+func (d *DependencyChecker) RequireKey(key string) *DependencyChecker {
+	d.mu.Lock()
+	d.keys = append(d.keys, key)
+	d.mu.Unlock()
+	return d
+}
+
+// Check 立即检查所有依赖并返回状态
+func (d *DependencyChecker) Check() []*DependencyStatus {
+	d.mu.Lock()
+	services := make(map[string]int, len(d.services))
+	for name, min := range d.services {
+		services[name] = min
+	}
+	keys := append([]string(nil), d.keys...)
+	d.mu.Unlock()
+
+	now := time.Now()
+	var result []*DependencyStatus
+	for _, name := range slices.Sorted(maps.Keys(services)) {
+		status := &DependencyStatus{Kind: "service", Name: name, CheckedAt: now}
+		entries, err := d.client.GetHealthyServices(name)
+		switch {
+		case err != nil:
+			status.Error = err.Error()
+		case len(entries) < services[name]:
+			status.Error = fmt.Sprintf("%d healthy instances, %d required", len(entries), services[name])
+		default:
+			status.Ready = true
+		}
+		result = append(result, status)
+	}
+
+	for _, key := range keys {
+		status := &DependencyStatus{Kind: "key", Name: key, CheckedAt: now}
+		pair, err := d.client.GetWithOptions(key, nil)
+		switch {
+		case err != nil:
+			status.Error = err.Error()
+		case pair == nil:
+			status.Error = "key not found"
+		default:
+			status.Ready = true
+		}
+		result = append(result, status)
+	}
+
+	d.mu.Lock()
+	d.last = result
+	d.mu.Unlock()
+	return result
+}
+
+// Status 返回最近一次检查的结果，尚未检查时立即执行一次
+func (d *DependencyChecker) Status() []*DependencyStatus {
+	d.mu.Lock()
+	last := d.last
+	d.mu.Unlock()
+	if last == nil {
+		return d.Check()
+	}
+	return last
+}
+
+// WaitAll 阻塞直到所有依赖就绪或上下文结束
+func (d *DependencyChecker) WaitAll(ctx context.Context) error {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		var missing []string
+		for _, status := range d.Check() {
+			if !status.Ready {
+				missing = append(missing, fmt.Sprintf("%s %s (%s)", status.Kind, status.Name, status.Error))
+			}
+		}
+		if len(missing) == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("dependencies not ready: %s", strings.Join(missing, ", "))
+		case <-d.client.ctx.Done():
+			return fmt.Errorf("consul client is closed")
+		case <-ticker.C:
+			d.client.logger.Printf("Waiting for dependencies: %s", strings.Join(missing, ", "))
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package consul
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// KVDiff 两组KV之间的差异，键为相对键名
+type KVDiff struct {
+	Added   map[string][]byte   `json:"added"`   // 只在新的一侧存在的键
+	Removed map[string][]byte   `json:"removed"` // 只在旧的一侧存在的键
+	Changed map[string]KVChange `json:"changed"` // 两侧都存在但值不同的键
+}
+
+// KVChange 发生变化的键的新旧值
+type KVChange struct {
+	Old []byte `json:"old"`
+	New []byte `json:"new"`
+}
+
+// DiffSnapshot 比较两组KV（如不同时间List得到的结果），before为旧的一侧，返回新增、删除和变更的键
+func DiffSnapshot(before, after map[string][]byte) *KVDiff {
+	diff := &KVDiff{
+		Added:   make(map[string][]byte),
+		Removed: make(map[string][]byte),
+		Changed: make(map[string]KVChange),
+	}
+	for key, value := range after {
+		oldValue, ok := before[key]
+		switch {
+		case !ok:
+			diff.Added[key] = value
+		case !bytes.Equal(oldValue, value):
+			diff.Changed[key] = KVChange{Old: oldValue, New: value}
+		}
+	}
+	for key, value := range before {
+		if _, ok := after[key]; !ok {
+			diff.Removed[key] = value
+		}
+	}
+	return diff
+}
+
+// DiffTrees 比较两个前缀下的KV，键按去掉各自前缀后的相对键名对齐，
+// 例如比较"staging/"和"prod/"，prefixA视为旧的一侧
+func (c *Client) DiffTrees(prefixA, prefixB string, opts ...QueryOption) (*KVDiff, error) {
+	a, err := c.relativeTree(prefixA, opts...)
+	if err != nil {
+		return nil, err
+	}
+	b, err := c.relativeTree(prefixB, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return DiffSnapshot(a, b), nil
+}
+
+// relativeTree 列出前缀下的KV，键去掉前缀
+func (c *Client) relativeTree(prefix string, opts ...QueryOption) (map[string][]byte, error) {
+	pairs, err := c.List(prefix, opts...)
+	if err != nil {
+		return nil, err
+	}
+	tree := make(map[string][]byte, len(pairs))
+	for key, value := range pairs {
+		if rel := strings.TrimPrefix(key, prefix); rel != "" {
+			tree[rel] = value
+		}
+	}
+	return tree, nil
+}
+
+// Empty 判断两侧是否完全一致
+func (d *KVDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// String 按键名排序输出差异，"+"为新增、"-"为删除、"~"为变更，便于在流水线中审阅
+func (d *KVDiff) String() string {
+	var b strings.Builder
+	for _, key := range slices.Sorted(maps.Keys(d.Added)) {
+		fmt.Fprintf(&b, "+ %s = %s\n", key, d.Added[key])
+	}
+	for _, key := range slices.Sorted(maps.Keys(d.Removed)) {
+		fmt.Fprintf(&b, "- %s = %s\n", key, d.Removed[key])
+	}
+	for _, key := range slices.Sorted(maps.Keys(d.Changed)) {
+		change := d.Changed[key]
+		fmt.Fprintf(&b, "~ %s: %s -> %s\n", key, change.Old, change.New)
+	}
+	return b.String()
+}
@@ -0,0 +1,161 @@
+package consul
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// Director 返回兼容httputil.ReverseProxy的Director，每个请求按调用器的过滤规则和负载均衡策略
+// 选择服务实例，便于现有的反向代理切换到Consul服务发现。需要失败重试时使用NewReverseProxy
+func (c *Client) Director(serviceName string, opts ...InvokerOption) func(*http.Request) {
+	return c.NewServiceInvoker(serviceName, opts...).Director()
+}
+
+// NewReverseProxy 创建转发到指定服务的httputil.ReverseProxy，
+// 连接失败或返回502时按调用器的重试次数换到其他实例重试，超过1MB或长度未知的请求体不重试
+func (c *Client) NewReverseProxy(serviceName string, opts ...InvokerOption) *httputil.ReverseProxy {
+	return c.NewServiceInvoker(serviceName, opts...).ReverseProxy()
+}
+
+// Director 返回使用该调用器选择实例的httputil.ReverseProxy Director
+func (i *ServiceInvoker) Director() func(*http.Request) {
+	return func(req *http.Request) {
+		req.URL.Scheme = "http"
+		req.URL.Host = ""
+		req.Host = ""
+
+		entry, err := i.selectFor(req, nil)
+		if err != nil {
+			// Host为空时请求失败，由ReverseProxy返回502
+			i.client.logger.Printf("Director failed to select instance for %s: %v", i.serviceName, err)
+			return
+		}
+		req.URL.Host, req.Host = i.resolveAddr(entry)
+		// 记录选中的实例ID，失败重试时据此排除已尝试的实例
+		*req = *req.WithContext(context.WithValue(req.Context(), selectedInstanceKey{}, entry.Service.ID))
+	}
+}
+
+// selectedInstanceKey Director选中的实例ID在请求context中的键
+type selectedInstanceKey struct{}
+
+// selectedInstance 返回Director为请求选中的实例ID，未经Director选择时返回空字符串
+func selectedInstance(req *http.Request) string {
+	id, _ := req.Context().Value(selectedInstanceKey{}).(string)
+	return id
+}
+
+// ReverseProxy 返回使用该调用器选择实例并在失败时换实例重试的httputil.ReverseProxy
+func (i *ServiceInvoker) ReverseProxy() *httputil.ReverseProxy {
+	return &httputil.ReverseProxy{
+		Director:  i.Director(),
+		Transport: &nextInstanceTransport{invoker: i, base: i.httpClient.Transport},
+	}
+}
+
+// selectFor 为请求选择实例，exclude中的实例ID不会被选中
+func (i *ServiceInvoker) selectFor(req *http.Request, exclude map[string]bool) (*api.ServiceEntry, error) {
+	services, err := i.candidates()
+	if err != nil {
+		return nil, err
+	}
+
+	var headers map[string]string
+	if i.splitHashHeader != "" {
+		headers = map[string]string{i.splitHashHeader: req.Header.Get(i.splitHashHeader)}
+	}
+	services = i.applyTrafficSplit(services, headers)
+
+	if len(exclude) > 0 {
+		remaining := make([]*api.ServiceEntry, 0, len(services))
+		for _, entry := range services {
+			if !exclude[entry.Service.ID] {
+				remaining = append(remaining, entry)
+			}
+		}
+		if len(remaining) == 0 {
+			return nil, fmt.Errorf("no other service instances available for %s", i.serviceName)
+		}
+		services = remaining
+	}
+	return i.pick(services, i.currentSettings().strategy), nil
+}
+
+// maxReplayBody 换实例重试时最多缓存的请求体字节数
+const maxReplayBody = 1 << 20
+
+// nextInstanceTransport 连接失败或返回502时换到其他实例重试
+type nextInstanceTransport struct {
+	invoker *ServiceInvoker
+	base    http.RoundTripper
+}
+
+func (t *nextInstanceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// 缓存长度已知的小请求体，使其可以在其他实例上重放
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	if !replayable && req.ContentLength >= 0 && req.ContentLength <= maxReplayBody {
+		data, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %v", err)
+		}
+		req = req.Clone(req.Context())
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(data)), nil
+		}
+		req.Body, _ = req.GetBody()
+		replayable = true
+	}
+
+	resp, err := t.base.RoundTrip(req)
+
+	// 按实例ID记录已尝试的实例，地址经网关改写后无法与实例对应
+	tried := map[string]bool{selectedInstance(req): true}
+	for attempt := 0; attempt < t.invoker.currentSettings().retryCount && replayable; attempt++ {
+		if err == nil && resp.StatusCode != http.StatusBadGateway {
+			break
+		}
+		if req.Context().Err() != nil {
+			break
+		}
+		entry, selectErr := t.invoker.selectFor(req, tried)
+		if selectErr != nil {
+			break
+		}
+
+		next := req.Clone(req.Context())
+		next.URL.Host, next.Host = t.invoker.resolveAddr(entry)
+		tried[entry.Service.ID] = true
+		if req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				break
+			}
+			next.Body = body
+		}
+
+		reason := "502 Bad Gateway"
+		if err != nil {
+			reason = err.Error()
+		} else {
+			resp.Body.Close()
+		}
+		t.invoker.client.logger.Printf("Retrying %s on next instance %s: %s", t.invoker.serviceName, targetHost(next), reason)
+		resp, err = t.base.RoundTrip(next)
+	}
+	return resp, err
+}
+
+// targetHost 返回请求的目标实例地址，经网关转发时为Host头，否则为URL中的地址
+func targetHost(req *http.Request) string {
+	if req.Host != "" {
+		return req.Host
+	}
+	return req.URL.Host
+}
@@ -0,0 +1,102 @@
+package consul
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// WithDNSFallback HTTP API不可用时通过Consul DNS接口（如"127.0.0.1:8600"）查询服务的SRV记录，
+// 使调用器在Agent API故障期间仍能发现实例。DNS结果不含标签、元数据和检查详情，
+// 因此带过滤表达式的查询不降级，按标签或元数据过滤的调用器在降级期间找不到实例
+func WithDNSFallback(resolverAddr string) Option {
+	return func(c *Config) {
+		c.dnsFallback = resolverAddr
+	}
+}
+
+// lookupDNS 通过SRV记录查询服务实例，返回的条目只包含地址、端口和数据中心，检查状态为passing
+func (c *Client) lookupDNS(name, dc string) ([]*api.ServiceEntry, error) {
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, c.config.dnsFallback)
+		},
+	}
+	ctx, cancel := context.WithTimeout(c.ctx, c.config.timeout)
+	defer cancel()
+
+	if dc == "" {
+		dc = c.config.datacenter
+	}
+	fqdn := name + ".service.consul."
+	if dc != "" {
+		fqdn = name + ".service." + dc + ".consul."
+	}
+	_, records, err := resolver.LookupSRV(ctx, "", "", fqdn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %v", fqdn, err)
+	}
+
+	entries := make([]*api.ServiceEntry, 0, len(records))
+	for _, srv := range records {
+		target := strings.TrimSuffix(srv.Target, ".")
+		addrs, err := resolver.LookupHost(ctx, target)
+		if err != nil || len(addrs) == 0 {
+			c.logger.Printf("Failed to resolve SRV target %s for %s: %v", target, name, err)
+			continue
+		}
+		// 目标形如node1.node.dc1.consul，未指定数据中心时从中取出
+		labels := strings.Split(target, ".")
+		node, nodeDC := labels[0], dc
+		if nodeDC == "" && len(labels) >= 4 && labels[1] == "node" {
+			nodeDC = labels[2]
+		}
+		entries = append(entries, &api.ServiceEntry{
+			Node: &api.Node{Node: node, Address: addrs[0], Datacenter: nodeDC},
+			Service: &api.AgentService{
+				ID:         fmt.Sprintf("%s-%s-%d", name, node, srv.Port),
+				Service:    name,
+				Address:    addrs[0],
+				Port:       int(srv.Port),
+				Datacenter: nodeDC,
+			},
+			Checks: api.HealthChecks{{Node: node, Name: "dns", Status: api.HealthPassing, ServiceName: name}},
+		})
+	}
+	return entries, nil
+}
+
+// apiUnavailable 判断错误是否表示Consul API不可用：连接失败等传输错误或5xx响应。
+// 403、400等请求被拒绝的错误说明API可用，降级到DNS无法解决问题并会掩盖ACL或参数错误，因此不降级
+func apiUnavailable(err error) bool {
+	var statusErr api.StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Code >= 500
+	}
+	var netErr net.Error
+	var urlErr *url.Error
+	return errors.As(err, &netErr) || errors.As(err, &urlErr)
+}
+
+// dnsFallback 在HTTP查询因Consul不可用而失败后尝试DNS降级，未启用或不适用时返回原错误
+func (c *Client) dnsFallback(name, dc, filter string, cause error) ([]*api.ServiceEntry, error) {
+	if c.config.dnsFallback == "" || filter != "" || c.ctx.Err() != nil || !apiUnavailable(cause) {
+		return nil, cause
+	}
+
+	start := time.Now()
+	entries, err := c.lookupDNS(name, dc)
+	if err != nil {
+		return nil, fmt.Errorf("%v (dns fallback failed: %v)", cause, err)
+	}
+	c.logger.Printf("Consul API unavailable, resolved %d instances of %s via DNS in %v: %v", len(entries), name, time.Since(start), cause)
+	return entries, nil
+}
@@ -0,0 +1,136 @@
+package consul
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// EnvSyncer 将KV前缀下的键映射为环境变量语义，便于12-factor应用迁移到Consul配置。
+// 键去掉前缀后转为大写，"/"、"-"、"."替换为"_"，例如前缀"app/"下的"db/host"对应DB_HOST。
+// 运行时修改进程环境变量并不安全，因此通过Getenv/LookupEnv读取，Consul中的值优先，
+// 不存在时回退到进程环境变量
+type EnvSyncer struct {
+	prefix string
+	handle *WatchHandle
+
+	mu        sync.RWMutex
+	values    map[string]string        // 环境变量名到值的映射
+	listeners []func(changed []string) // 变更回调
+}
+
+// EnvSync 加载prefix下的所有键并持续监听变化，客户端关闭或调用Stop后停止同步
+func (c *Client) EnvSync(prefix string) (*EnvSyncer, error) {
+	if prefix == "" {
+		return nil, fmt.Errorf("prefix cannot be empty")
+	}
+
+	pairs, _, err := c.client.KV().List(prefix, c.kvQueryOptions(prefix))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list env prefix %s: %v", prefix, err)
+	}
+	s := &EnvSyncer{prefix: prefix, values: envValues(prefix, pairs)}
+
+	s.handle, err = c.WatchKeyPrefix(prefix, func(pairs api.KVPairs) {
+		s.update(envValues(prefix, pairs))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// LookupEnv 返回环境变量的值，语义同os.LookupEnv
+func (s *EnvSyncer) LookupEnv(name string) (string, bool) {
+	s.mu.RLock()
+	value, ok := s.values[name]
+	s.mu.RUnlock()
+	if ok {
+		return value, true
+	}
+	return os.LookupEnv(name)
+}
+
+// Getenv 返回环境变量的值，不存在时返回空字符串，语义同os.Getenv
+func (s *EnvSyncer) Getenv(name string) string {
+	value, _ := s.LookupEnv(name)
+	return value
+}
+
+// Expand 按当前值替换字符串中的$VAR或${VAR}，语义同os.ExpandEnv
+func (s *EnvSyncer) Expand(str string) string {
+	return os.Expand(str, s.Getenv)
+}
+
+// Environ 返回从Consul同步的变量，格式为"KEY=value"并按名称排序，不包含进程环境变量
+func (s *EnvSyncer) Environ() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	env := make([]string, 0, len(s.values))
+	for _, name := range slices.Sorted(maps.Keys(s.values)) {
+		env = append(env, name+"="+s.values[name])
+	}
+	return env
+}
+
+// OnChange 注册变更回调，changed为新增、修改或删除的变量名（已排序）
+func (s *EnvSyncer) OnChange(fn func(changed []string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, fn)
+}
+
+// Stop 停止同步，已同步的值仍可读取
+func (s *EnvSyncer) Stop() {
+	s.handle.Stop()
+}
+
+// update 替换为最新的值并通知变化的变量
+func (s *EnvSyncer) update(values map[string]string) {
+	s.mu.Lock()
+	var changed []string
+	for name, value := range values {
+		if old, ok := s.values[name]; !ok || old != value {
+			changed = append(changed, name)
+		}
+	}
+	for name := range s.values {
+		if _, ok := values[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+	s.values = values
+	listeners := slices.Clone(s.listeners)
+	s.mu.Unlock()
+
+	if len(changed) == 0 {
+		return
+	}
+	slices.Sort(changed)
+	for _, fn := range listeners {
+		fn(changed)
+	}
+}
+
+// envValues 将前缀下的KV转换为环境变量，目录键被忽略
+func envValues(prefix string, pairs api.KVPairs) map[string]string {
+	values := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		rel := strings.TrimPrefix(pair.Key, prefix)
+		if rel == "" || strings.HasSuffix(rel, "/") {
+			continue
+		}
+		values[EnvName(rel)] = string(pair.Value)
+	}
+	return values
+}
+
+// EnvName 将KV相对路径转换为环境变量名，例如"db/host"转换为DB_HOST
+func EnvName(key string) string {
+	return strings.ToUpper(strings.NewReplacer("/", "_", "-", "_", ".", "_").Replace(strings.Trim(key, "/")))
+}
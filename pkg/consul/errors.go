@@ -0,0 +1,74 @@
+package consul
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// defaultErrorBodyLimit 错误响应体默认最多读取的字节数
+const defaultErrorBodyLimit = 64 * 1024
+
+// HTTPError 表示下游服务返回的非2xx响应
+type HTTPError struct {
+	StatusCode int         // 状态码
+	Status     string      // 状态行，例如：404 Not Found
+	Header     http.Header // 响应头
+	Body       []byte      // 响应体（超过限制的部分会被截断）
+	Truncated  bool        // 响应体是否被截断
+	Payload    interface{} // 错误解码器解析出的结构化错误
+}
+
+// Error 实现error接口
+func (e *HTTPError) Error() string {
+	if len(e.Body) == 0 {
+		return fmt.Sprintf("service returned error status: %s", e.Status)
+	}
+	return fmt.Sprintf("service returned error status: %s: %s", e.Status, e.Body)
+}
+
+// ErrorDecoder 将错误响应体解析为结构化错误，结果保存在HTTPError.Payload中
+type ErrorDecoder func(statusCode int, body []byte) (interface{}, error)
+
+// WithErrorDecoder 设置错误响应体的解码器
+func WithErrorDecoder(decoder ErrorDecoder) InvokerOption {
+	return func(i *ServiceInvoker) {
+		i.errorDecoder = decoder
+	}
+}
+
+// WithErrorBodyLimit 设置错误响应体最多读取的字节数，默认64KB
+func WithErrorBodyLimit(limit int64) InvokerOption {
+	return func(i *ServiceInvoker) {
+		i.errorBodyLimit = limit
+	}
+}
+
+// newHTTPError 读取错误响应并构造HTTPError
+func (i *ServiceInvoker) newHTTPError(resp *http.Response) *HTTPError {
+	e := &HTTPError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Header:     resp.Header,
+	}
+
+	limit := i.errorBodyLimit
+	if limit <= 0 {
+		limit = defaultErrorBodyLimit
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if int64(len(body)) > limit {
+		body = body[:limit]
+		e.Truncated = true
+	}
+	e.Body = body
+
+	if i.errorDecoder != nil && len(body) > 0 {
+		if payload, err := i.errorDecoder(resp.StatusCode, body); err == nil {
+			e.Payload = payload
+		} else {
+			i.client.logger.Printf("Failed to decode error body from %s: %v", i.serviceName, err)
+		}
+	}
+	return e
+}
@@ -0,0 +1,76 @@
+package consul
+
+import (
+	"time"
+)
+
+// EventType 客户端内部事件的类型
+type EventType string
+
+const (
+	EventServiceRegistered   EventType = "ServiceRegistered"   // 通过本客户端注册了服务
+	EventServiceDeregistered EventType = "ServiceDeregistered" // 通过本客户端注销了服务
+	EventWatchUpdated        EventType = "WatchUpdated"        // 监听的配置键发生变化或被删除
+	EventInstanceEjected     EventType = "InstanceEjected"     // 失效实例被回收器注销
+	EventLeaderElected       EventType = "LeaderElected"       // 本实例成为领导者，预留给选主功能，目前不会产生
+	EventConsulReconnected   EventType = "ConsulReconnected"   // 切换到其他Consul地址或监听在失败后恢复
+	EventTokenRenewalFailed  EventType = "TokenRenewalFailed"  // 登录换取的Token续期或过期后重新登录失败
+)
+
+// eventBufferSize 每个订阅者通道的缓冲大小
+const eventBufferSize = 64
+
+// Event 客户端内部事件，未涉及的字段为空
+type Event struct {
+	Type      EventType `json:"type"`                 // 事件类型
+	Time      time.Time `json:"time"`                 // 发生时间
+	Service   string    `json:"service,omitempty"`    // 服务名称
+	ServiceID string    `json:"service_id,omitempty"` // 服务实例ID
+	Node      string    `json:"node,omitempty"`       // 实例所在节点
+	Key       string    `json:"key,omitempty"`        // 配置键
+	Address   string    `json:"address,omitempty"`    // Consul地址
+	Message   string    `json:"message,omitempty"`    // 附加说明
+}
+
+// Events 订阅客户端内部事件，每次调用返回一个新的通道，便于应用记录日志、告警或展示。
+// 通道缓冲64个事件，消费过慢时新事件会被丢弃；客户端关闭后通道被关闭
+func (c *Client) Events() <-chan Event {
+	ch := make(chan Event, eventBufferSize)
+
+	c.eventsMu.Lock()
+	defer c.eventsMu.Unlock()
+	if c.ctx.Err() != nil {
+		close(ch)
+		return ch
+	}
+	if c.subscribers == nil {
+		go func() {
+			<-c.ctx.Done()
+			c.eventsMu.Lock()
+			defer c.eventsMu.Unlock()
+			for _, sub := range c.subscribers {
+				close(sub)
+			}
+			c.subscribers = nil
+		}()
+	}
+	c.subscribers = append(c.subscribers, ch)
+	return ch
+}
+
+// emit 向所有订阅者发送事件，不阻塞调用方
+func (c *Client) emit(event Event) {
+	c.eventsMu.Lock()
+	defer c.eventsMu.Unlock()
+	if len(c.subscribers) == 0 || c.ctx.Err() != nil {
+		return
+	}
+
+	event.Time = time.Now()
+	for _, sub := range c.subscribers {
+		select {
+		case sub <- event:
+		default:
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package consul
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// WithAddresses 设置多个Consul地址，主地址（第一个）不可达时自动切换到其他地址，
+// 主地址恢复后自动切回
+func WithAddresses(addresses []string) Option {
+	return func(c *Config) {
+		c.addresses = addresses
+		if len(addresses) > 0 {
+			c.address = addresses[0]
+		}
+	}
+}
+
+// WithFailoverProbeInterval 设置故障切换后探测主地址是否恢复的间隔，默认10秒
+func WithFailoverProbeInterval(interval time.Duration) Option {
+	return func(c *Config) {
+		c.probeInterval = interval
+	}
+}
+
+// failoverTransport 在多个Consul地址之间故障切换的HTTP传输层
+type failoverTransport struct {
+	base      http.RoundTripper
+	addresses []string
+	scheme    string
+	logger    *log.Logger
+	onSwitch  func(from, to string) // 地址切换后的回调
+
+	mu     sync.RWMutex
+	active int // 当前使用的地址序号
+}
+
+// newFailoverTransport 创建故障切换传输层
+func newFailoverTransport(base http.RoundTripper, addresses []string, scheme string, logger *log.Logger) *failoverTransport {
+	return &failoverTransport{
+		base:      base,
+		addresses: addresses,
+		scheme:    scheme,
+		logger:    logger,
+	}
+}
+
+// RoundTrip 依次尝试当前地址及其后的地址，直到请求成功
+func (t *failoverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := t.current()
+
+	var lastErr error
+	for n := 0; n < len(t.addresses); n++ {
+		idx := (start + n) % len(t.addresses)
+
+		attempt := req.Clone(req.Context())
+		attempt.URL.Host = t.addresses[idx]
+		attempt.Host = t.addresses[idx]
+		if n > 0 && req.Body != nil {
+			// 请求体无法重放时不再尝试其他地址
+			if req.GetBody == nil {
+				break
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				break
+			}
+			attempt.Body = body
+		}
+
+		resp, err := t.base.RoundTrip(attempt)
+		if err == nil {
+			if idx != start {
+				t.switchTo(idx)
+			}
+			return resp, nil
+		}
+
+		lastErr = err
+		if req.Context().Err() != nil {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+// current 返回当前使用的地址序号
+func (t *failoverTransport) current() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.active
+}
+
+// switchTo 切换当前使用的地址
+func (t *failoverTransport) switchTo(idx int) {
+	t.mu.Lock()
+	prev := t.active
+	t.active = idx
+	t.mu.Unlock()
+
+	if prev != idx {
+		t.logger.Printf("Consul address switched from %s to %s", t.addresses[prev], t.addresses[idx])
+		if t.onSwitch != nil {
+			t.onSwitch(t.addresses[prev], t.addresses[idx])
+		}
+	}
+}
+
+// probe 检查指定地址的Consul是否可用
+func (t *failoverTransport) probe(ctx context.Context, idx int) error {
+	url := fmt.Sprintf("%s://%s/v1/status/leader", t.scheme, t.addresses[idx])
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return nil
+}
+
+// runFailback 定期探测主地址，恢复后切回
+func (t *failoverTransport) runFailback(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Second * 10
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if t.current() == 0 {
+				continue
+			}
+			probeCtx, cancel := context.WithTimeout(ctx, interval)
+			err := t.probe(probeCtx, 0)
+			cancel()
+			if err == nil {
+				t.switchTo(0)
+			}
+		}
+	}
+}
+
+// ActiveAddress 返回当前使用的Consul地址
+func (c *Client) ActiveAddress() string {
+	if c.failover == nil {
+		return c.config.address
+	}
+	return c.failover.addresses[c.failover.current()]
+}
@@ -0,0 +1,37 @@
+package consul
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+)
+
+// FallbackFunc 定义降级处理函数，在所有重试都失败后调用，
+// 可返回缓存数据或默认响应，避免将原始错误暴露给终端用户
+type FallbackFunc func(req *http.Request) (*http.Response, error)
+
+// WithFallback 设置调用彻底失败时的降级处理函数
+func WithFallback(fn FallbackFunc) InvokerOption {
+	return func(i *ServiceInvoker) {
+		i.fallback = fn
+	}
+}
+
+// runFallback 构造原始请求并执行降级处理
+func (i *ServiceInvoker) runFallback(method, path string, headers map[string]string, body []byte, cause error) (*http.Response, error) {
+	i.client.logger.Printf("Invoking fallback for service %s: %v", i.serviceName, cause)
+
+	req, err := http.NewRequest(method, "http://"+i.serviceName+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("%v (fallback failed: %v)", cause, err)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := i.fallback(req)
+	if err != nil {
+		return nil, fmt.Errorf("%v (fallback failed: %v)", cause, err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,92 @@
+// fault.go
+package consul
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// FaultInjection 故障注入配置，用于在预发环境验证重试、降级等容错逻辑，
+// 各比例取值0~1，为0表示不注入
+type FaultInjection struct {
+	DropRate    float64       // 直接失败（模拟网络错误）的请求比例
+	Latency     time.Duration // 注入的额外延迟
+	LatencyRate float64       // 注入延迟的请求比例
+	StatusCode  int           // 返回的状态码，例如503
+	StatusRate  float64       // 直接返回StatusCode的请求比例
+}
+
+// WithFaultInjection 对发往Consul的请求注入故障
+func WithFaultInjection(f FaultInjection) Option {
+	return func(c *Config) {
+		c.faults = &f
+	}
+}
+
+// WithInvokeFaultInjection 对服务调用请求注入故障
+func WithInvokeFaultInjection(f FaultInjection) InvokerOption {
+	return func(i *ServiceInvoker) {
+		i.faults = &f
+	}
+}
+
+// faultTransport 按故障注入配置包装底层传输层
+type faultTransport struct {
+	base   http.RoundTripper
+	faults FaultInjection
+}
+
+// newFaultTransport 创建故障注入传输层
+func newFaultTransport(base http.RoundTripper, f FaultInjection) http.RoundTripper {
+	return &faultTransport{base: base, faults: f}
+}
+
+// RoundTrip 实现http.RoundTripper
+func (t *faultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	f := t.faults
+
+	if f.Latency > 0 && rand.Float64() < f.LatencyRate {
+		timer := time.NewTimer(f.Latency)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			closeRequestBody(req)
+			return nil, req.Context().Err()
+		}
+	}
+
+	if rand.Float64() < f.DropRate {
+		closeRequestBody(req)
+		return nil, fmt.Errorf("fault injection: request to %s dropped", req.URL.Host)
+	}
+
+	if f.StatusCode != 0 && rand.Float64() < f.StatusRate {
+		closeRequestBody(req)
+		body := fmt.Sprintf("fault injection: status %d", f.StatusCode)
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", f.StatusCode, http.StatusText(f.StatusCode)),
+			StatusCode:    f.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": {"text/plain"}},
+			Body:          io.NopCloser(strings.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+
+	return t.base.RoundTrip(req)
+}
+
+// closeRequestBody 未转发的请求也需要关闭请求体
+func closeRequestBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
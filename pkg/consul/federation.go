@@ -0,0 +1,91 @@
+package consul
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// WithDatacenters 从多个WAN联邦的数据中心聚合健康实例，由负载均衡策略在全部实例中选择，
+// 用于全局负载均衡；部分数据中心不可达时使用其余数据中心的实例
+func WithDatacenters(datacenters ...string) InvokerOption {
+	return func(i *ServiceInvoker) {
+		i.datacenters = datacenters
+	}
+}
+
+// AggregateServices 并发查询多个数据中心的健康实例并合并，实例的DC字段为所在数据中心，
+// 结果按datacenters的顺序排列；datacenters为空时查询所有已知的数据中心。
+// 部分数据中心查询失败时返回其余数据中心的实例并记录日志，全部失败时返回错误
+func (c *Client) AggregateServices(name string, datacenters []string, opts ...QueryOption) ([]Instance, error) {
+	if name == "" {
+		return nil, fmt.Errorf("service name cannot be empty")
+	}
+	if len(datacenters) == 0 {
+		dcs, err := c.client.Catalog().Datacenters()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list datacenters: %v", err)
+		}
+		datacenters = dcs
+	}
+
+	entries, err := c.aggregateHealthy(datacenters, func(dc string) ([]*api.ServiceEntry, error) {
+		q := c.queryOptions(append(opts, WithQueryDatacenter(dc))...)
+		services, _, err := c.client.Health().Service(name, "", true, q)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get healthy services: %v", err)
+		}
+		return services, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newInstances(entries), nil
+}
+
+// aggregateHealthy 并发对每个数据中心执行fetch并按数据中心顺序合并，
+// 未标注数据中心的条目补上查询的数据中心
+func (c *Client) aggregateHealthy(datacenters []string, fetch func(dc string) ([]*api.ServiceEntry, error)) ([]*api.ServiceEntry, error) {
+	results := make([][]*api.ServiceEntry, len(datacenters))
+	errs := make([]error, len(datacenters))
+	var wg sync.WaitGroup
+	for idx, dc := range datacenters {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[idx], errs[idx] = fetch(dc)
+		}()
+	}
+	wg.Wait()
+
+	var merged []*api.ServiceEntry
+	var failed []string
+	for idx, dc := range datacenters {
+		if errs[idx] != nil {
+			c.logger.Printf("Failed to query datacenter %s: %v", dc, errs[idx])
+			failed = append(failed, fmt.Sprintf("%s: %v", dc, errs[idx]))
+			continue
+		}
+		for _, entry := range results[idx] {
+			merged = append(merged, withDatacenter(entry, dc))
+		}
+	}
+	if len(failed) == len(datacenters) {
+		return nil, fmt.Errorf("failed to query all datacenters: %s", strings.Join(failed, "; "))
+	}
+	return merged, nil
+}
+
+// withDatacenter 返回标注了数据中心的条目，条目可能被查询缓存共享，需要修改时复制
+func withDatacenter(entry *api.ServiceEntry, dc string) *api.ServiceEntry {
+	if entry.Node == nil || entry.Node.Datacenter != "" {
+		return entry
+	}
+	e := *entry
+	node := *entry.Node
+	node.Datacenter = dc
+	e.Node = &node
+	return &e
+}
@@ -0,0 +1,139 @@
+package consul
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// GatewayMode 定义调用其他数据中心的实例时如何改写目标地址
+type GatewayMode int
+
+const (
+	// GatewayNone 直接使用实例地址（默认）
+	GatewayNone GatewayMode = iota
+	// GatewayRemoteWAN 使用远端实例的WAN地址（服务或节点TaggedAddresses中的wan），
+	// 适用于数据中心之间只能通过WAN地址互通的网络
+	GatewayRemoteWAN
+	// GatewayLocal 将请求发往本地数据中心的通用HTTP网关，Host头为原实例地址，由网关按Host头转发到远端数据中心。
+	// Consul mesh gateway按TLS SNI而非Host头路由，不能用作此模式的网关；需通过WithGatewayService指定网关服务名
+	GatewayLocal
+)
+
+// WithGatewayMode 设置调用其他数据中心的实例时的地址改写方式，与WithDatacenters配合使用，
+// 本地数据中心的实例不受影响
+func WithGatewayMode(mode GatewayMode) InvokerOption {
+	return func(i *ServiceInvoker) {
+		i.gatewayMode = mode
+	}
+}
+
+// WithGatewayService 设置GatewayLocal模式下本地HTTP网关的服务名，该网关需按Host头转发请求
+func WithGatewayService(name string) InvokerOption {
+	return func(i *ServiceInvoker) {
+		i.gatewayService = name
+	}
+}
+
+// LocalDatacenter 返回客户端所在的数据中心，未通过WithDatacenter指定时从本地Agent获取
+func (c *Client) LocalDatacenter() (string, error) {
+	if c.config.datacenter != "" {
+		return c.config.datacenter, nil
+	}
+
+	c.mu.RLock()
+	dc := c.localDC
+	c.mu.RUnlock()
+	if dc != "" {
+		return dc, nil
+	}
+
+	self, err := c.client.Agent().Self()
+	if err != nil {
+		return "", fmt.Errorf("failed to get agent info: %v", err)
+	}
+	dc, _ = self["Config"]["Datacenter"].(string)
+	if dc == "" {
+		return "", fmt.Errorf("agent did not report its datacenter")
+	}
+	c.mu.Lock()
+	c.localDC = dc
+	c.mu.Unlock()
+	return dc, nil
+}
+
+// resolveAddr 返回请求实例时连接的地址，以及需要保留的原实例地址（用作Host头，未经网关时为空）
+func (i *ServiceInvoker) resolveAddr(entry *api.ServiceEntry) (addr, host string) {
+	inst := newInstance(entry)
+	addr = i.instanceAddr(entry)
+	if i.gatewayMode == GatewayNone || inst.DC == "" {
+		return addr, ""
+	}
+	local, err := i.client.LocalDatacenter()
+	if err != nil {
+		i.client.logger.Printf("Failed to determine local datacenter for %s, using instance address: %v", i.serviceName, err)
+		return addr, ""
+	}
+	if inst.DC == local {
+		return addr, ""
+	}
+
+	switch i.gatewayMode {
+	case GatewayRemoteWAN:
+		if wan := taggedAddress(entry, "wan"); wan != "" {
+			return wan, ""
+		}
+		i.client.logger.Printf("Instance %s in %s has no WAN address, using %s", inst.ID, inst.DC, addr)
+	case GatewayLocal:
+		gateway, err := i.localGateway()
+		if err == nil {
+			return gateway, addr
+		}
+		i.client.logger.Printf("Failed to find local gateway for %s, using instance address: %v", i.serviceName, err)
+	}
+	return addr, ""
+}
+
+// taggedAddress 返回实例在TaggedAddresses中指定名称的地址，服务未设置时使用节点的同名地址和服务端口，
+// 都未设置时返回空字符串
+func taggedAddress(entry *api.ServiceEntry, tag string) string {
+	svc := entry.Service
+	if svc == nil {
+		return ""
+	}
+	if tagged, ok := svc.TaggedAddresses[tag]; ok && tagged.Address != "" {
+		port := tagged.Port
+		if port == 0 {
+			port = svc.Port
+		}
+		return joinHostPort(tagged.Address, port)
+	}
+	if node := entry.Node; node != nil {
+		if tagged := node.TaggedAddresses[tag]; tagged != "" {
+			return joinHostPort(tagged, svc.Port)
+		}
+	}
+	return ""
+}
+
+// localGateway 随机选择一个本地数据中心的健康网关，返回其LAN地址
+func (i *ServiceInvoker) localGateway() (string, error) {
+	name := i.gatewayService
+	if name == "" {
+		return "", fmt.Errorf("gateway service is not set")
+	}
+	entries, err := i.client.lookupHealthy(name, "", "", nil)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 0 {
+		return "", fmt.Errorf("no healthy gateway instances found for %s", name)
+	}
+
+	entry := entries[rand.Intn(len(entries))]
+	if lan, ok := entry.Service.TaggedAddresses["lan"]; ok && lan.Address != "" {
+		return joinHostPort(lan.Address, lan.Port), nil
+	}
+	return newInstance(entry).Addr(), nil
+}
@@ -0,0 +1,241 @@
+package consul
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// GCConfig 失效实例回收器的配置
+type GCConfig struct {
+	Services      []string      // 需要扫描的服务名
+	CriticalAfter time.Duration // 实例持续不健康超过该时间后注销，默认10分钟
+	Interval      time.Duration // 后台扫描间隔，默认1分钟
+	OwnerKey      string        // 只回收Meta[OwnerKey]==OwnerValue的实例，为空时不限制
+	OwnerValue    string
+	DryRun        bool // 只记录日志不注销
+}
+
+// GCStats 记录回收器的运行统计
+type GCStats struct {
+	Runs         int       // 扫描次数
+	Deregistered int       // 累计注销的实例数
+	Pending      int       // 当前不健康但未到期的实例数
+	LastRun      time.Time // 最近一次扫描时间
+	LastError    error     // 最近一次扫描的错误
+}
+
+// GarbageCollector 定期扫描目录中的服务实例，注销检查持续critical
+// 或所在节点已失联（serfHealth为critical）超过阈值的实例。
+// 不健康的起始时间以回收器首次观察到为准，重启后重新计时
+type GarbageCollector struct {
+	client *Client
+	config GCConfig
+
+	mu            sync.Mutex
+	criticalSince map[string]staleSince // 节点/实例ID -> 首次观察到不健康的时间
+	stats         GCStats
+	stopCh        chan struct{}
+	running       bool
+}
+
+// NewGarbageCollector 创建失效实例回收器
+func (c *Client) NewGarbageCollector(config GCConfig) *GarbageCollector {
+	if config.CriticalAfter <= 0 {
+		config.CriticalAfter = 10 * time.Minute
+	}
+	if config.Interval <= 0 {
+		config.Interval = time.Minute
+	}
+	return &GarbageCollector{
+		client:        c,
+		config:        config,
+		criticalSince: make(map[string]staleSince),
+	}
+}
+
+// staleSince 实例首次被观察到不健康的时间及其所属服务
+type staleSince struct {
+	service string
+	since   time.Time
+}
+
+// Collect 立即执行一次扫描，返回本次注销的实例
+func (gc *GarbageCollector) Collect() ([]Instance, error) {
+	localNode, err := gc.client.client.Agent().NodeName()
+	if err != nil {
+		err = fmt.Errorf("failed to get agent node name: %v", err)
+		gc.record(0, err)
+		return nil, err
+	}
+
+	now := time.Now()
+	seen := make(map[string]bool)
+	failed := make(map[string]bool) // 本次查询失败的服务，其实例保留原有计时
+	var expired []*api.ServiceEntry
+	var lastErr error
+
+	for _, name := range gc.config.Services {
+		entries, _, err := gc.client.client.Health().Service(name, "", false, gc.client.queryOptions())
+		if err != nil {
+			lastErr = fmt.Errorf("failed to get service %s: %v", name, err)
+			failed[name] = true
+			continue
+		}
+		for _, entry := range entries {
+			if !gc.owned(entry.Service) || !stale(entry) {
+				continue
+			}
+			key := entry.Node.Node + "/" + entry.Service.ID
+			seen[key] = true
+
+			gc.mu.Lock()
+			entrySince, ok := gc.criticalSince[key]
+			if !ok {
+				entrySince = staleSince{service: name, since: now}
+				gc.criticalSince[key] = entrySince
+			}
+			since := entrySince.since
+			gc.mu.Unlock()
+
+			if now.Sub(since) >= gc.config.CriticalAfter {
+				expired = append(expired, entry)
+			}
+		}
+	}
+
+	var removed []Instance
+	for _, entry := range expired {
+		key := entry.Node.Node + "/" + entry.Service.ID
+		if gc.config.DryRun {
+			gc.client.logger.Printf("GC would deregister stale instance %s on node %s", entry.Service.ID, entry.Node.Node)
+			continue
+		}
+		if err := gc.deregister(entry, localNode); err != nil {
+			lastErr = err
+			continue
+		}
+		delete(seen, key)
+		removed = append(removed, newInstance(entry))
+		gc.client.logger.Printf("GC deregistered stale instance %s on node %s", entry.Service.ID, entry.Node.Node)
+		gc.client.emit(Event{Type: EventInstanceEjected, Service: entry.Service.Service, ServiceID: entry.Service.ID, Node: entry.Node.Node})
+	}
+
+	// 恢复健康或已消失的实例重新计时，查询失败的服务无法判断，保留计时
+	gc.mu.Lock()
+	for key, entry := range gc.criticalSince {
+		if !seen[key] && !failed[entry.service] {
+			delete(gc.criticalSince, key)
+		}
+	}
+	gc.mu.Unlock()
+
+	gc.record(len(removed), lastErr)
+	return removed, lastErr
+}
+
+// deregister 注销实例，本地Agent上的实例通过Agent注销，避免反熵同步将其重新写回目录
+func (gc *GarbageCollector) deregister(entry *api.ServiceEntry, localNode string) error {
+	audit := AuditEntry{Action: AuditDeregister, ServiceID: entry.Service.ID, Service: entry.Service.Service, Reason: "garbage collected"}
+	if entry.Node.Node == localNode {
+		err := gc.client.client.Agent().ServiceDeregister(entry.Service.ID)
+		gc.client.audit(audit, err)
+		if err != nil {
+			return fmt.Errorf("failed to deregister service %s: %v", entry.Service.ID, err)
+		}
+		return nil
+	}
+
+	dereg := &api.CatalogDeregistration{
+		Node:       entry.Node.Node,
+		Datacenter: entry.Node.Datacenter,
+		ServiceID:  entry.Service.ID,
+	}
+	audit.Node = entry.Node.Node
+	_, err := gc.client.client.Catalog().Deregister(dereg, gc.client.writeOptions())
+	gc.client.audit(audit, err)
+	if err != nil {
+		return fmt.Errorf("failed to deregister service %s on node %s: %v", entry.Service.ID, entry.Node.Node, err)
+	}
+	return nil
+}
+
+// owned 判断实例是否属于本回收器管理
+func (gc *GarbageCollector) owned(svc *api.AgentService) bool {
+	if gc.config.OwnerKey == "" {
+		return true
+	}
+	return svc.Meta[gc.config.OwnerKey] == gc.config.OwnerValue
+}
+
+// stale 判断实例的服务检查或节点检查是否为critical
+func stale(entry *api.ServiceEntry) bool {
+	for _, check := range entry.Checks {
+		if check.Status == api.HealthCritical {
+			return true
+		}
+	}
+	return false
+}
+
+// Start 启动后台定期扫描
+func (gc *GarbageCollector) Start() {
+	gc.mu.Lock()
+	if gc.running {
+		gc.mu.Unlock()
+		return
+	}
+	gc.running = true
+	gc.stopCh = make(chan struct{})
+	stopCh := gc.stopCh
+	gc.mu.Unlock()
+
+	gc.client.goWorker("gc", func() {
+		ticker := time.NewTicker(gc.config.Interval)
+		defer ticker.Stop()
+
+		gc.Collect()
+		for {
+			select {
+			case <-gc.client.ctx.Done():
+				return
+			case <-stopCh:
+				return
+			case <-ticker.C:
+				if _, err := gc.Collect(); err != nil {
+					gc.client.logger.Printf("GC failed: %v", err)
+				}
+			}
+		}
+	})
+}
+
+// Stop 停止后台扫描
+func (gc *GarbageCollector) Stop() {
+	gc.mu.Lock()
+	defer gc.mu.Unlock()
+	if gc.running {
+		close(gc.stopCh)
+		gc.running = false
+	}
+}
+
+// Stats 返回回收器的运行统计
+func (gc *GarbageCollector) Stats() GCStats {
+	gc.mu.Lock()
+	defer gc.mu.Unlock()
+	return gc.stats
+}
+
+// record 更新运行统计
+func (gc *GarbageCollector) record(deregistered int, err error) {
+	gc.mu.Lock()
+	defer gc.mu.Unlock()
+	gc.stats.Runs++
+	gc.stats.Deregistered += deregistered
+	gc.stats.Pending = len(gc.criticalSince)
+	gc.stats.LastRun = time.Now()
+	gc.stats.LastError = err
+}
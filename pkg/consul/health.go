@@ -2,6 +2,8 @@ package consul
 
 import (
 	"fmt"
+	"slices"
+	"strconv"
 	"time"
 
 	"github.com/hashicorp/consul/api"
@@ -17,16 +19,95 @@ type CheckConfig struct {
 	TLSSkipVerify   bool                // 是否跳过TLS验证
 	Method          string              // HTTP方法
 	Header          map[string][]string // HTTP头
+
+	ID   string        // 检查ID，TTL检查需要通过ID上报状态
+	Name string        // 检查名称，默认为"service:<服务ID> check"
+	TTL  time.Duration // TTL检查的有效期，设置后由应用通过UpdateCheckStatus上报状态
+
+	Args              []string // 脚本检查执行的命令及参数，需要Agent开启enable_script_checks
+	DockerContainerID string   // Docker检查的容器ID，与Args配合在容器内执行脚本
+	Shell             string   // Docker检查使用的Shell，默认为/bin/sh
+
+	AliasService string // 别名检查镜像的服务实例ID，例如代理镜像其后端服务的健康状态
+	AliasNode    string // 别名检查镜像的节点，与AliasService同时设置时表示该节点上的服务
+}
+
+// agentCheck 转换为Consul的服务检查定义
+func (check *CheckConfig) agentCheck(serviceID string) *api.AgentServiceCheck {
+	ac := &api.AgentServiceCheck{
+		CheckID:                        check.ID,
+		Name:                           fmt.Sprintf("service:%s check", serviceID),
+		HTTP:                           check.HTTP,
+		TCP:                            check.TCP,
+		Interval:                       check.Interval.String(),
+		Timeout:                        check.Timeout.String(),
+		DeregisterCriticalServiceAfter: check.DeregisterAfter.String(),
+		TLSSkipVerify:                  check.TLSSkipVerify,
+		Method:                         check.Method,
+		Header:                         check.Header,
+		Args:                           check.Args,
+		DockerContainerID:              check.DockerContainerID,
+		Shell:                          check.Shell,
+		AliasService:                   check.AliasService,
+		AliasNode:                      check.AliasNode,
+	}
+	if check.Name != "" {
+		ac.Name = check.Name
+	}
+	if check.TTL > 0 {
+		ac.TTL = check.TTL.String()
+	}
+	// TTL检查和别名检查不需要执行间隔
+	if check.TTL > 0 || check.AliasService != "" || check.AliasNode != "" {
+		ac.Interval = ""
+		ac.Timeout = ""
+	}
+	return ac
+}
+
+// AliasCheck 返回镜像另一个服务实例健康状态的别名检查
+func AliasCheck(serviceID string) *CheckConfig {
+	return &CheckConfig{
+		Name:         "alias:" + serviceID,
+		AliasService: serviceID,
+	}
+}
+
+// Status 健康检查状态
+type Status string
+
+const (
+	StatusPassing  Status = api.HealthPassing  // 通过
+	StatusWarning  Status = api.HealthWarning  // 警告
+	StatusCritical Status = api.HealthCritical // 失败
+)
+
+// UpdateCheckStatus 上报TTL检查的状态，note会显示在检查的输出中，
+// 应用可据此将数据库连通性、队列积压等综合判断推送到Consul
+func (c *Client) UpdateCheckStatus(checkID string, status Status, note string) error {
+	if checkID == "" {
+		return fmt.Errorf("check ID cannot be empty")
+	}
+	switch status {
+	case StatusPassing, StatusWarning, StatusCritical:
+	default:
+		return fmt.Errorf("invalid check status: %s", status)
+	}
+
+	if err := c.client.Agent().UpdateTTL(checkID, note, string(status)); err != nil {
+		return fmt.Errorf("failed to update check status: %v", err)
+	}
+	return nil
 }
 
 // GetHealthChecks 获取服务的健康检查状态
-func (c *Client) GetHealthChecks(serviceID string) (api.HealthChecks, error) {
+func (c *Client) GetHealthChecks(serviceID string, opts ...QueryOption) (api.HealthChecks, error) {
 	if serviceID == "" {
 		return nil, fmt.Errorf("service ID cannot be empty")
 	}
 
 	// 先获取服务的所有实例
-	services, err := c.GetHealthyServices(serviceID)
+	services, err := c.GetHealthyServices(serviceID, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -40,15 +121,220 @@ func (c *Client) GetHealthChecks(serviceID string) (api.HealthChecks, error) {
 	return allChecks, nil
 }
 
+// AddHealthCheck 为已注册的服务追加健康检查，不会重新注册服务，
+// 已有的检查和服务定义保持不变。未设置ID的检查默认使用"service:<服务ID>:<名称>"
+func (c *Client) AddHealthCheck(serviceID string, checks ...*CheckConfig) error {
+	if serviceID == "" {
+		return fmt.Errorf("service ID cannot be empty")
+	}
+	if err := c.requireAgentService(serviceID); err != nil {
+		return err
+	}
+
+	for _, check := range checks {
+		if err := c.registerCheck(serviceID, check); err != nil {
+			return err
+		}
+	}
+
+	// 合并到本地记录的副本，重新注册服务时保留原有定义和追加的检查，且不修改调用方的配置
+	c.mu.Lock()
+	if cfg, ok := c.services[serviceID]; ok {
+		merged := *cfg
+		merged.Checks = append(slices.Clone(cfg.Checks), checks...)
+		c.services[serviceID] = &merged
+	}
+	c.mu.Unlock()
+	return nil
+}
+
+// UpdateCheck 更新服务的健康检查定义，按检查ID替换已有的检查
+func (c *Client) UpdateCheck(serviceID string, check *CheckConfig) error {
+	if serviceID == "" {
+		return fmt.Errorf("service ID cannot be empty")
+	}
+	if check == nil || check.ID == "" {
+		return fmt.Errorf("check ID cannot be empty")
+	}
+	if err := c.requireAgentService(serviceID); err != nil {
+		return err
+	}
+
+	if err := c.registerCheck(serviceID, check); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	if cfg, ok := c.services[serviceID]; ok {
+		merged := *cfg
+		merged.Checks = slices.Clone(cfg.Checks)
+		i := slices.IndexFunc(merged.Checks, func(existing *CheckConfig) bool { return existing.ID == check.ID })
+		if i >= 0 {
+			merged.Checks[i] = check
+		} else {
+			merged.Checks = append(merged.Checks, check)
+		}
+		c.services[serviceID] = &merged
+	}
+	c.mu.Unlock()
+	return nil
+}
+
+// ListChecks 列出本地Agent上属于指定服务的健康检查，key为检查ID
+func (c *Client) ListChecks(serviceID string) (map[string]*api.AgentCheck, error) {
+	if serviceID == "" {
+		return nil, fmt.Errorf("service ID cannot be empty")
+	}
+
+	checks, err := c.client.Agent().Checks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checks: %v", err)
+	}
+	result := make(map[string]*api.AgentCheck)
+	for id, check := range checks {
+		if check.ServiceID == serviceID {
+			result[id] = check
+		}
+	}
+	return result, nil
+}
+
+// requireAgentService 确认服务已注册到本地Agent，避免检查挂到不存在的服务上
+func (c *Client) requireAgentService(serviceID string) error {
+	if _, _, err := c.client.Agent().Service(serviceID, nil); err != nil {
+		return fmt.Errorf("failed to get service %s: %v", serviceID, err)
+	}
+	return nil
+}
+
+// registerCheck 通过Agent单独注册服务的健康检查
+func (c *Client) registerCheck(serviceID string, check *CheckConfig) error {
+	if check == nil {
+		return fmt.Errorf("check cannot be nil")
+	}
+
+	ac := check.agentCheck(serviceID)
+	reg := &api.AgentCheckRegistration{
+		ID:                check.ID,
+		Name:              ac.Name,
+		ServiceID:         serviceID,
+		AgentServiceCheck: *ac,
+	}
+	if reg.ID == "" {
+		reg.ID = "service:" + serviceID + ":" + reg.Name
+	}
+	reg.AgentServiceCheck.CheckID = ""
+	reg.AgentServiceCheck.Name = ""
+
+	if err := c.client.Agent().CheckRegisterOpts(reg, &api.QueryOptions{Token: c.serviceToken(serviceID)}); err != nil {
+		return fmt.Errorf("failed to register check: %v", err)
+	}
+	c.logger.Printf("Health check registered: %s (service: %s)", reg.ID, serviceID)
+	return nil
+}
+
 // GetHealthyServices 获取健康的服务列表
-func (c *Client) GetHealthyServices(name string) ([]*api.ServiceEntry, error) {
+func (c *Client) GetHealthyServices(name string, opts ...QueryOption) ([]*api.ServiceEntry, error) {
 	if name == "" {
 		return nil, fmt.Errorf("service name cannot be empty")
 	}
 
-	services, _, err := c.client.Health().Service(name, "", true, nil)
+	services, _, err := c.client.Health().Service(name, "", true, c.queryOptions(opts...))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get healthy services: %v", err)
 	}
 	return services, nil
 }
+
+// HealthFilter 自定义健康实例的判定规则，零值等同于只接受所有检查都通过的实例
+type HealthFilter struct {
+	IncludeWarning bool                // 是否接受warning状态的实例，局部故障时宁可路由到warning实例也不直接失败
+	RequiredChecks []string            // 必须为passing的检查（按名称或ID匹配），IncludeWarning时也不放宽
+	Predicate      func(Instance) bool // 自定义判定，在上述规则之后执行
+}
+
+// match 检查服务条目是否满足健康规则
+func (f *HealthFilter) match(entry *api.ServiceEntry) bool {
+	for _, check := range entry.Checks {
+		switch check.Status {
+		case api.HealthPassing:
+		case api.HealthWarning:
+			if !f.IncludeWarning {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	for _, name := range f.RequiredChecks {
+		passed := false
+		for _, check := range entry.Checks {
+			if (check.Name == name || check.CheckID == name) && check.Status == api.HealthPassing {
+				passed = true
+				break
+			}
+		}
+		if !passed {
+			return false
+		}
+	}
+	return f.Predicate == nil || f.Predicate(newInstance(entry))
+}
+
+// GetServicesByHealth 按自定义健康规则获取服务实例
+func (c *Client) GetServicesByHealth(name string, filter HealthFilter, opts ...QueryOption) ([]*api.ServiceEntry, error) {
+	if name == "" {
+		return nil, fmt.Errorf("service name cannot be empty")
+	}
+
+	services, _, err := c.client.Health().Service(name, "", false, c.queryOptions(opts...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get services: %v", err)
+	}
+	return filterHealth(services, &filter), nil
+}
+
+// filterHealth 过滤出满足健康规则的服务条目
+func filterHealth(services []*api.ServiceEntry, filter *HealthFilter) []*api.ServiceEntry {
+	var matched []*api.ServiceEntry
+	for _, entry := range services {
+		if filter.match(entry) {
+			matched = append(matched, entry)
+		}
+	}
+	return matched
+}
+
+// lookupHealthy 查询健康实例，优先使用预加载的缓存，
+// 相同服务和过滤条件的并发查询合并为一次Consul请求，每个调用方得到独立的切片。
+// health不为nil时查询全部实例并按自定义健康规则过滤，dc为空时查询客户端默认的数据中心
+func (c *Client) lookupHealthy(name, dc, filter string, health *HealthFilter) ([]*api.ServiceEntry, error) {
+	if dc == "" && filter == "" && health == nil {
+		if entries, ok := c.prewarmedServices(name); ok {
+			return entries, nil
+		}
+	}
+
+	passingOnly := health == nil
+	key := name + "\x00" + dc + "\x00" + filter + "\x00" + strconv.FormatBool(passingOnly)
+	v, err, _ := c.lookups.Do(key, func() (interface{}, error) {
+		opts := []QueryOption{WithQueryFilter(filter)}
+		if dc != "" {
+			opts = append(opts, WithQueryDatacenter(dc))
+		}
+		services, _, err := c.client.Health().Service(name, "", passingOnly, c.queryOptions(opts...))
+		if err != nil {
+			return c.dnsFallback(name, dc, filter, fmt.Errorf("failed to get healthy services: %w", err))
+		}
+		return services, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	services := v.([]*api.ServiceEntry)
+	if health != nil {
+		return filterHealth(services, health), nil
+	}
+	return slices.Clone(services), nil
+}
@@ -0,0 +1,211 @@
+// health_registry.go
+package consul
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HealthFunc 组件健康检查函数，返回nil表示健康
+type HealthFunc func() error
+
+// HealthRegistry 汇总应用各组件（数据库、队列等）的健康检查，
+// 可通过NewHealthHandler暴露为HTTP检查，或通过StartHealthReporter上报到TTL检查
+type HealthRegistry struct {
+	mu     sync.RWMutex
+	checks map[string]HealthFunc
+}
+
+// ComponentHealth 单个组件的检查结果
+type ComponentHealth struct {
+	Status   string        `json:"status"`          // passing 或 critical
+	Error    string        `json:"error,omitempty"` // 失败原因
+	Duration time.Duration `json:"duration"`        // 检查耗时
+}
+
+// HealthReport 所有组件的汇总检查结果
+type HealthReport struct {
+	Status     string                     `json:"status"`     // 全部组件健康时为passing，否则为critical
+	Components map[string]ComponentHealth `json:"components"` // 各组件的检查结果
+}
+
+// NewHealthRegistry 创建组件健康检查注册表
+func NewHealthRegistry() *HealthRegistry {
+	return &HealthRegistry{checks: make(map[string]HealthFunc)}
+}
+
+// Register 注册组件的健康检查，同名组件会被覆盖
+func (r *HealthRegistry) Register(name string, fn HealthFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks[name] = fn
+}
+
+// Unregister 移除组件的健康检查
+func (r *HealthRegistry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.checks, name)
+}
+
+// Check 并发执行所有组件的健康检查并汇总结果
+func (r *HealthRegistry) Check() *HealthReport {
+	r.mu.RLock()
+	checks := make(map[string]HealthFunc, len(r.checks))
+	for name, fn := range r.checks {
+		checks[name] = fn
+	}
+	r.mu.RUnlock()
+
+	report := &HealthReport{
+		Status:     string(StatusPassing),
+		Components: make(map[string]ComponentHealth, len(checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, fn := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			err := fn()
+			result := ComponentHealth{Status: string(StatusPassing), Duration: time.Since(start)}
+			if err != nil {
+				result.Status = string(StatusCritical)
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			report.Components[name] = result
+			if err != nil {
+				report.Status = string(StatusCritical)
+			}
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+// summary 返回失败组件的简要说明，用作TTL检查的输出
+func (h *HealthReport) summary() string {
+	var failed []string
+	for _, name := range slices.Sorted(maps.Keys(h.Components)) {
+		if c := h.Components[name]; c.Status != string(StatusPassing) {
+			failed = append(failed, name+": "+c.Error)
+		}
+	}
+	if len(failed) == 0 {
+		return "all components healthy"
+	}
+	return strings.Join(failed, "; ")
+}
+
+// NewHealthHandler 返回报告组件健康状态的http.Handler，
+// 全部组件健康时返回200，否则返回503，可直接用作Consul的HTTP检查
+func NewHealthHandler(registry *HealthRegistry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := registry.Check()
+
+		w.Header().Set("Content-Type", "application/json")
+		if report.Status != string(StatusPassing) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(report)
+	})
+}
+
+// defaultHealthReportInterval 健康上报的默认间隔
+const defaultHealthReportInterval = 10 * time.Second
+
+// StartHealthReporter 按interval执行注册表中的健康检查，并将汇总结果上报到TTL检查，
+// interval应小于检查的TTL，不大于0时默认10秒，客户端关闭时停止
+func (c *Client) StartHealthReporter(registry *HealthRegistry, checkID string, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultHealthReportInterval
+	}
+	report := func() {
+		result := registry.Check()
+		if err := c.UpdateCheckStatus(checkID, Status(result.Status), result.summary()); err != nil {
+			c.logger.Printf("Failed to report health for check %s: %v", checkID, err)
+		}
+	}
+
+	c.goWorker("health reporter "+checkID, func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		report()
+		for {
+			select {
+			case <-c.ctx.Done():
+				return
+			case <-ticker.C:
+				report()
+			}
+		}
+	})
+}
+
+// HealthEndpointOptions 健康端点的挂载选项
+type HealthEndpointOptions struct {
+	Registry   *HealthRegistry // 组件健康检查，为nil时视为全部健康
+	Client     *Client         // 设置后/ready同时要求Consul可用
+	HealthPath string          // 组件健康端点，默认/health
+	ReadyPath  string          // 就绪端点，默认/ready
+	LivePath   string          // 存活端点，默认/live
+}
+
+// readyReport 就绪端点的响应
+type readyReport struct {
+	*HealthReport
+	Consul string `json:"consul,omitempty"` // Consul不可用时的错误信息
+}
+
+// MountHealthEndpoints 在mux上挂载标准的健康端点：
+// /health 返回组件检查结果（可用作Consul的HTTP检查），
+// /ready 要求组件健康且Consul可用（用作就绪探针），/live 进程存活即返回200（用作存活探针）
+func MountHealthEndpoints(mux *http.ServeMux, opts *HealthEndpointOptions) {
+	if opts == nil {
+		opts = &HealthEndpointOptions{}
+	}
+	registry := opts.Registry
+	if registry == nil {
+		registry = NewHealthRegistry()
+	}
+	path := func(p, def string) string {
+		if p == "" {
+			return def
+		}
+		return p
+	}
+
+	mux.Handle(path(opts.HealthPath, "/health"), NewHealthHandler(registry))
+
+	mux.HandleFunc(path(opts.ReadyPath, "/ready"), func(w http.ResponseWriter, r *http.Request) {
+		report := readyReport{HealthReport: registry.Check()}
+		if opts.Client != nil {
+			if err := opts.Client.Healthy(); err != nil {
+				report.Status = string(StatusCritical)
+				report.Consul = err.Error()
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if report.Status != string(StatusPassing) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(report)
+	})
+
+	mux.HandleFunc(path(opts.LivePath, "/live"), func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"status": string(StatusPassing)})
+	})
+}
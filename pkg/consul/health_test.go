@@ -0,0 +1,54 @@
+package consul_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/stones-hub/taurus-pro-consul/pkg/consul"
+	"github.com/stones-hub/taurus-pro-consul/pkg/consul/consultest"
+)
+
+func TestAddHealthCheckKeepsServiceDefinition(t *testing.T) {
+	c, srv := consultest.NewFakeClient(t)
+
+	cfg := &consul.ServiceConfig{
+		Name:    "orders",
+		ID:      "orders-1",
+		Address: "10.0.0.1",
+		Port:    8080,
+		Tags:    []string{"v1", "primary"},
+	}
+	if err := c.RegisterService(cfg); err != nil {
+		t.Fatalf("RegisterService: %v", err)
+	}
+
+	check := &consul.CheckConfig{ID: "orders-1-ttl", Name: "ttl", TTL: 30 * time.Second}
+	if err := c.AddHealthCheck("orders-1", check); err != nil {
+		t.Fatalf("AddHealthCheck: %v", err)
+	}
+
+	raw, err := api.NewClient(&api.Config{Address: srv.Addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc, _, err := raw.Agent().Service("orders-1", nil)
+	if err != nil {
+		t.Fatalf("get service: %v", err)
+	}
+	if svc.Service != "orders" || svc.Port != 8080 || svc.Address != "10.0.0.1" {
+		t.Fatalf("service changed to %s %s:%d", svc.Service, svc.Address, svc.Port)
+	}
+	if !slices.Equal(svc.Tags, []string{"v1", "primary"}) {
+		t.Fatalf("tags changed to %v", svc.Tags)
+	}
+
+	checks, err := raw.Agent().Checks()
+	if err != nil {
+		t.Fatalf("list checks: %v", err)
+	}
+	if got, ok := checks["orders-1-ttl"]; !ok || got.ServiceID != "orders-1" {
+		t.Fatalf("check not registered for service: %+v", checks)
+	}
+}
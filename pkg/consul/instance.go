@@ -0,0 +1,100 @@
+// instance.go
+package consul
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// Instance 服务实例，屏蔽Consul API的类型细节
+type Instance struct {
+	ID      string            // 实例ID
+	Service string            // 服务名
+	Address string            // 实例地址，未设置时为节点地址
+	Port    int               // 实例端口
+	Tags    []string          // 标签
+	Meta    map[string]string // 元数据
+	Health  string            // 聚合的健康状态：passing、warning、critical
+	DC      string            // 数据中心
+
+	Checks map[string]string // 各健康检查的状态，key为检查名称
+}
+
+// Addr 返回host:port形式的地址，IPv6地址会加上方括号，主机名原样保留
+func (i Instance) Addr() string {
+	return joinHostPort(i.Address, i.Port)
+}
+
+// joinHostPort 拼接host:port，注册时已带方括号的IPv6地址不会重复添加
+func joinHostPort(host string, port int) string {
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+// baseURL 返回http://host:port形式的URL前缀，IPv6链路本地地址的zone按URL规范转义为%25
+func baseURL(addr string) string {
+	return (&url.URL{Scheme: "http", Host: addr}).String()
+}
+
+// Healthy 检查实例的所有健康检查是否都通过
+func (i Instance) Healthy() bool {
+	return i.Health == api.HealthPassing
+}
+
+// Instances 获取带有全部tags的健康服务实例
+func (c *Client) Instances(name string, tags []string, opts ...QueryOption) ([]Instance, error) {
+	entries, err := c.GetHealthyServices(name, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []*api.ServiceEntry
+	for _, entry := range entries {
+		if containsAll(entry.Service.Tags, tags) {
+			matched = append(matched, entry)
+		}
+	}
+	return newInstances(matched), nil
+}
+
+// newInstance 将Consul的服务条目转换为实例
+func newInstance(entry *api.ServiceEntry) Instance {
+	inst := Instance{
+		Health: entry.Checks.AggregatedStatus(),
+		Checks: make(map[string]string, len(entry.Checks)),
+	}
+	for _, check := range entry.Checks {
+		inst.Checks[check.Name] = check.Status
+	}
+	if svc := entry.Service; svc != nil {
+		inst.ID = svc.ID
+		inst.Service = svc.Service
+		inst.Address = svc.Address
+		inst.Port = svc.Port
+		inst.Tags = svc.Tags
+		inst.Meta = svc.Meta
+		inst.DC = svc.Datacenter
+	}
+	if node := entry.Node; node != nil {
+		if inst.Address == "" {
+			inst.Address = node.Address
+		}
+		if inst.DC == "" {
+			inst.DC = node.Datacenter
+		}
+	}
+	return inst
+}
+
+// newInstances 批量转换服务条目
+func newInstances(entries []*api.ServiceEntry) []Instance {
+	instances := make([]Instance, 0, len(entries))
+	for _, entry := range entries {
+		instances = append(instances, newInstance(entry))
+	}
+	return instances
+}
@@ -0,0 +1,36 @@
+package consul
+
+import "testing"
+
+func TestInstanceAddressFormatting(t *testing.T) {
+	tests := []struct {
+		name     string
+		address  string
+		addr     string
+		baseURL  string
+		checkURL string
+	}{
+		{"ipv4", "10.0.0.1", "10.0.0.1:8080", "http://10.0.0.1:8080", "http://10.0.0.1:8080/health"},
+		{"hostname", "orders.internal", "orders.internal:8080", "http://orders.internal:8080", "http://orders.internal:8080/health"},
+		{"bare ipv6", "2001:db8::1", "[2001:db8::1]:8080", "http://[2001:db8::1]:8080", "http://[2001:db8::1]:8080/health"},
+		{"bracketed ipv6", "[2001:db8::1]", "[2001:db8::1]:8080", "http://[2001:db8::1]:8080", "http://[2001:db8::1]:8080/health"},
+		{"zoned ipv6", "fe80::1%eth0", "[fe80::1%eth0]:8080", "http://[fe80::1%25eth0]:8080", "http://[fe80::1%25eth0]:8080/health"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inst := Instance{Address: tt.address, Port: 8080}
+			if got := inst.Addr(); got != tt.addr {
+				t.Errorf("Addr() = %q, want %q", got, tt.addr)
+			}
+			if got := joinHostPort(tt.address, 8080); got != tt.addr {
+				t.Errorf("joinHostPort() = %q, want %q", got, tt.addr)
+			}
+			if got := baseURL(inst.Addr()); got != tt.baseURL {
+				t.Errorf("baseURL() = %q, want %q", got, tt.baseURL)
+			}
+			if got := rewriteCheckURL("/health", tt.address, 8080); got != tt.checkURL {
+				t.Errorf("rewriteCheckURL() = %q, want %q", got, tt.checkURL)
+			}
+		})
+	}
+}
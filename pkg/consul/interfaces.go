@@ -0,0 +1,51 @@
+// interfaces.go
+package consul
+
+import "github.com/hashicorp/consul/api"
+
+// KVStore 键值存储能力
+type KVStore interface {
+	Put(key string, value []byte, opts ...WriteOption) error
+	Get(key string, opts ...QueryOption) ([]byte, error)
+	Delete(key string, opts ...WriteOption) error
+	List(prefix string, opts ...QueryOption) (map[string][]byte, error)
+	CAS(key string, value []byte, version uint64, opts ...WriteOption) (bool, error)
+	UpdateKey(key string, fn UpdateFunc, opts ...WriteOption) error
+}
+
+// Registrar 服务注册能力
+type Registrar interface {
+	RegisterService(cfg *ServiceConfig, opts ...WriteOption) error
+	DeregisterService(serviceID string, opts ...QueryOption) error
+}
+
+// HealthAPI 健康检查查询能力
+type HealthAPI interface {
+	GetHealthChecks(serviceID string, opts ...QueryOption) (api.HealthChecks, error)
+	GetHealthyServices(name string, opts ...QueryOption) ([]*api.ServiceEntry, error)
+}
+
+// Discoverer 服务发现能力
+type Discoverer interface {
+	GetService(name string, tag string, opts ...QueryOption) ([]*api.ServiceEntry, error)
+	GetAllServices(opts ...QueryOption) (map[string][]string, error)
+	Instances(name string, tags []string, opts ...QueryOption) ([]Instance, error)
+}
+
+// Watcher 配置监听能力
+type Watcher interface {
+	WatchConfig(key string, config interface{}, opts *WatchOptions) error
+	WatchConfigSet(targets map[string]interface{}, onChange ConfigSetHandler, opts *WatchOptions) error
+}
+
+// API 组合了客户端的全部基础能力，业务代码依赖该接口（或其中的小接口）
+// 即可在单元测试中替换为consultest.MockClient
+type API interface {
+	KVStore
+	Registrar
+	HealthAPI
+	Discoverer
+	Watcher
+}
+
+var _ API = (*Client)(nil)
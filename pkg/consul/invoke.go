@@ -1,11 +1,14 @@
 package consul
 
 import (
-	"encoding/json"
+	"bytes"
 	"fmt"
+	"io"
+	"maps"
 	"math/rand"
 	"net/http"
-	"strings"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/consul/api"
@@ -21,6 +24,8 @@ const (
 	RoundRobin
 	// LeastConn 最少连接数
 	LeastConn
+	// NearestFirst 选择网络坐标估算RTT最小的服务实例
+	NearestFirst
 )
 
 // ServiceInvoker 服务调用器
@@ -34,6 +39,47 @@ type ServiceInvoker struct {
 	retryInterval time.Duration
 	currentIndex  int // 用于轮询策略
 	httpClient    *http.Client
+	mu            sync.RWMutex // 保护可在运行时调整的设置
+
+	connectTimeout time.Duration // 建立连接的超时时间
+
+	// 实例过滤
+	filter       string
+	metaFilter   map[string]string
+	metaPatterns map[string]versionConstraint // 元数据过滤中的版本约束
+	metaErr      error                        // 元数据过滤中版本约束的解析错误
+	versionKey   string                       // 版本号所在的元数据字段
+	versionRange versionConstraint            // 版本约束
+	versionErr   error                        // 版本约束解析错误
+
+	// 流量分配
+	splitWeights    map[string]int // 流量分配权重
+	splitMetaKey    string         // 流量分组匹配的元数据字段
+	splitHashHeader string         // 用于确定性分组的请求头
+
+	// 影子流量
+	shadowTarget string          // 影子目标，服务名或"tag:"前缀的标签
+	shadowRate   float64         // 影子流量采样比例
+	shadowTag    string          // 影子实例标签
+	shadow       *ServiceInvoker // 影子调用器
+
+	fallback    FallbackFunc // 调用彻底失败时的降级处理
+	settingsKey string       // 存储调用器设置的KV键
+
+	errorDecoder   ErrorDecoder // 错误响应体解码器
+	errorBodyLimit int64        // 错误响应体最多读取的字节数
+
+	correlationHeaders []string // 需要透传的关联请求头
+
+	faults    *FaultInjection   // 对服务调用的故障注入
+	transport http.RoundTripper // 调用器独立的传输层，为nil时使用客户端共享的传输层
+
+	healthFilter *HealthFilter // 自定义健康规则，为nil时只调用所有检查都通过的实例
+
+	datacenters    []string    // 聚合实例的数据中心，为空时只查询客户端默认的数据中心
+	gatewayMode    GatewayMode // 调用其他数据中心的实例时的地址改写方式
+	gatewayService string      // 本地网关的服务名
+	addressMode    AddressMode // 使用实例的哪个地址
 }
 
 // InvokerOption 定义服务调用器的配置选项
@@ -46,6 +92,58 @@ func WithTags(tags []string) InvokerOption {
 	}
 }
 
+// WithFilter 设置Consul过滤表达式，例如：Service.Meta.version == "2.0"
+func WithFilter(filter string) InvokerOption {
+	return func(i *ServiceInvoker) {
+		i.filter = filter
+	}
+}
+
+// WithMetaFilter 按服务元数据过滤实例，值可以是精确值，
+// 也可以是版本约束表达式，例如：{"env": "prod", "version": "1.2.x"}。
+// 版本约束无法解析时调用返回错误，与WithVersionConstraint一致
+func WithMetaFilter(meta map[string]string) InvokerOption {
+	return func(i *ServiceInvoker) {
+		i.metaFilter = meta
+		i.metaPatterns = make(map[string]versionConstraint)
+		i.metaErr = nil
+		for _, k := range slices.Sorted(maps.Keys(meta)) {
+			v := meta[k]
+			if !isVersionPattern(v) {
+				continue
+			}
+			c, err := parseConstraint(v)
+			if err != nil {
+				i.metaErr = fmt.Errorf("%s=%q: %v", k, v, err)
+				return
+			}
+			i.metaPatterns[k] = c
+		}
+	}
+}
+
+// WithVersionConstraint 只调用版本满足约束的实例，版本号取自元数据的version字段，
+// 例如：">=1.2.0 <2.0.0"、"^1.2"、"1.4.x"
+func WithVersionConstraint(constraint string) InvokerOption {
+	return func(i *ServiceInvoker) {
+		i.versionRange, i.versionErr = parseConstraint(constraint)
+	}
+}
+
+// WithVersionMetaKey 设置版本号所在的元数据字段，默认为version
+func WithVersionMetaKey(key string) InvokerOption {
+	return func(i *ServiceInvoker) {
+		i.versionKey = key
+	}
+}
+
+// WithHealthFilter 使用自定义健康规则选择实例，例如接受warning状态的实例
+func WithHealthFilter(filter HealthFilter) InvokerOption {
+	return func(i *ServiceInvoker) {
+		i.healthFilter = &filter
+	}
+}
+
 // WithStrategy 设置负载均衡策略
 func WithStrategy(strategy LoadBalanceStrategy) InvokerOption {
 	return func(i *ServiceInvoker) {
@@ -53,11 +151,10 @@ func WithStrategy(strategy LoadBalanceStrategy) InvokerOption {
 	}
 }
 
-// WithTimeout 设置调用超时时间
+// WithInvokeTimeout 设置每次调用尝试的默认超时时间，可通过WithCallTimeout按调用覆盖
 func WithInvokeTimeout(timeout time.Duration) InvokerOption {
 	return func(i *ServiceInvoker) {
 		i.timeout = timeout
-		i.httpClient.Timeout = timeout
 	}
 }
 
@@ -78,6 +175,7 @@ func (c *Client) NewServiceInvoker(serviceName string, opts ...InvokerOption) *S
 		timeout:       time.Second * 30,
 		retryCount:    3,
 		retryInterval: time.Second,
+		versionKey:    "version",
 		httpClient:    &http.Client{},
 	}
 
@@ -86,16 +184,68 @@ func (c *Client) NewServiceInvoker(serviceName string, opts ...InvokerOption) *S
 		opt(invoker)
 	}
 
-	// 设置HTTP客户端超时
-	invoker.httpClient.Timeout = invoker.timeout
+	// 超时通过每次请求的上下文控制，以支持按调用覆盖；
+	// 未指定传输层时复用客户端共享的连接池
+	invoker.httpClient.Transport = invoker.transport
+	if invoker.httpClient.Transport == nil {
+		invoker.httpClient.Transport = c.sharedTransport(invoker.connectTimeout)
+	}
+	if invoker.faults != nil {
+		invoker.httpClient.Transport = newFaultTransport(invoker.httpClient.Transport, *invoker.faults)
+	}
+
+	// 初始化影子流量
+	invoker.initShadow()
+
+	// 从KV加载并监听调用器设置
+	invoker.watchSettings()
 
 	return invoker
 }
 
 // Call 调用服务的指定API
-func (i *ServiceInvoker) Call(method, path string, headers map[string]string, body []byte) (*http.Response, error) {
-	// 获取健康的服务实例
-	services, err := i.client.GetHealthyServices(i.serviceName)
+func (i *ServiceInvoker) Call(method, path string, headers map[string]string, body []byte, opts ...CallOption) (*http.Response, error) {
+	callOpts := newCallOptions(opts)
+	return i.invoke(method, path, i.withCorrelation(headers, callOpts.ctx, ""), body, callOpts)
+}
+
+// invoke 执行调用并在失败时降级，headers由调用方新建，键已规范化
+func (i *ServiceInvoker) invoke(method, path string, headers map[string]string, body []byte, callOpts *callOptions) (*http.Response, error) {
+	resp, err := i.call(method, path, headers, body, callOpts)
+	if err != nil && i.fallback != nil {
+		return i.runFallback(method, path, headers, body, err)
+	}
+	return resp, err
+}
+
+// Instances 返回调用器当前可选的服务实例（已按标签、元数据和版本过滤）
+func (i *ServiceInvoker) Instances() ([]Instance, error) {
+	services, err := i.candidates()
+	if err != nil {
+		return nil, err
+	}
+	return newInstances(services), nil
+}
+
+// candidates 获取健康实例并按标签、元数据和版本过滤
+func (i *ServiceInvoker) candidates() ([]*api.ServiceEntry, error) {
+	if i.versionErr != nil {
+		return nil, fmt.Errorf("invalid version constraint: %v", i.versionErr)
+	}
+	if i.metaErr != nil {
+		return nil, fmt.Errorf("invalid meta filter: %v", i.metaErr)
+	}
+
+	// 获取健康的服务实例，并发调用共享同一次查询
+	var services []*api.ServiceEntry
+	var err error
+	if len(i.datacenters) > 0 {
+		services, err = i.client.aggregateHealthy(i.datacenters, func(dc string) ([]*api.ServiceEntry, error) {
+			return i.client.lookupHealthy(i.serviceName, dc, i.filter, i.healthFilter)
+		})
+	} else {
+		services, err = i.client.lookupHealthy(i.serviceName, "", i.filter, i.healthFilter)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get service instances: %v", err)
 	}
@@ -104,93 +254,123 @@ func (i *ServiceInvoker) Call(method, path string, headers map[string]string, bo
 		return nil, fmt.Errorf("no healthy service instances found for %s", i.serviceName)
 	}
 
-	// 根据标签过滤服务实例
-	if len(i.tags) > 0 {
-		var filtered []*api.ServiceEntry
-		for _, service := range services {
-			if containsAll(service.Service.Tags, i.tags) {
-				filtered = append(filtered, service)
-			}
-		}
-		services = filtered
+	// 根据标签和元数据过滤服务实例
+	services = i.filterServices(services)
+	if len(services) == 0 {
+		return nil, fmt.Errorf("no service instances found matching tags, meta or version for %s", i.serviceName)
 	}
+	return services, nil
+}
 
-	if len(services) == 0 {
-		return nil, fmt.Errorf("no service instances found matching tags for %s", i.serviceName)
+// call 选择服务实例并执行请求（带重试）
+func (i *ServiceInvoker) call(method, path string, headers map[string]string, body []byte, callOpts *callOptions) (*http.Response, error) {
+	services, err := i.candidates()
+	if err != nil {
+		return nil, err
 	}
 
-	// 选择服务实例
-	var selectedService *api.ServiceEntry
-	switch i.strategy {
-	case Random:
-		selectedService = services[rand.Intn(len(services))]
-	case RoundRobin:
-		selectedService = services[i.currentIndex%len(services)]
-		i.currentIndex++
-	case LeastConn:
-		// 这里可以实现最少连接数的选择逻辑
-		// 需要维护每个实例的连接数统计
-		selectedService = services[0]
+	// 按流量分配权重选出实例分组
+	services = i.applyTrafficSplit(services, headers)
+
+	// 流式请求体只能发送一次，不镜像也不重试
+	settings := i.currentSettings()
+	retryCount := settings.retryCount
+	var reqBody io.Reader = bytes.NewReader(body)
+	if callOpts.body != nil {
+		reqBody = callOpts.body
+		retryCount = 0
+	} else {
+		// 镜像影子流量
+		i.mirror(method, path, headers, body)
 	}
 
-	// 构建请求URL
-	url := fmt.Sprintf("http://%s:%d%s",
-		selectedService.Service.Address,
-		selectedService.Service.Port,
-		path)
+	// 选择服务实例
+	selectedService := i.pick(services, settings.strategy)
+
+	// 构建请求URL，经网关转发时以原实例地址作为Host头
+	addr, host := i.resolveAddr(selectedService)
+	url := baseURL(addr) + path
 
 	// 创建请求
-	req, err := http.NewRequest(method, url, strings.NewReader(string(body)))
+	req, err := http.NewRequest(method, url, reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %v", err)
 	}
+	if host != "" {
+		req.Host = host
+	}
 
-	// 添加请求头
+	// 添加请求头，键已在withCorrelation中规范化
+	req.Header = make(http.Header, len(headers))
 	for k, v := range headers {
-		req.Header.Set(k, v)
+		req.Header[k] = []string{v}
 	}
 
 	// 执行请求（带重试）
-	var resp *http.Response
 	var lastErr error
 
-	for attempt := 0; attempt <= i.retryCount; attempt++ {
-		resp, err = i.httpClient.Do(req)
+	for attempt := 0; attempt <= retryCount; attempt++ {
+		resp, err := i.do(req, callOpts, settings.timeout)
 		if err == nil {
 			return resp, nil
 		}
 
 		lastErr = err
-		if attempt < i.retryCount {
-			time.Sleep(i.retryInterval)
+		if callOpts.ctx.Err() != nil {
+			break
+		}
+		if attempt < retryCount {
+			time.Sleep(settings.retryInterval)
 			i.client.logger.Printf("Retry attempt %d for service %s: %v", attempt+1, i.serviceName, err)
 		}
 	}
 
-	return nil, fmt.Errorf("service call failed after %d attempts: %v", i.retryCount+1, lastErr)
+	return nil, fmt.Errorf("service call failed after %d attempts: %v", retryCount+1, lastErr)
+}
+
+// pick 按负载均衡策略选择服务实例，services不能为空
+func (i *ServiceInvoker) pick(services []*api.ServiceEntry, strategy LoadBalanceStrategy) *api.ServiceEntry {
+	switch strategy {
+	case Random:
+		return services[rand.Intn(len(services))]
+	case RoundRobin:
+		return services[i.nextIndex()%len(services)]
+	case NearestFirst:
+		if nearest, err := i.client.NearestN(services, 1); err == nil && len(nearest) > 0 {
+			return nearest[0]
+		} else if err != nil {
+			i.client.logger.Printf("Failed to sort instances by RTT for %s: %v", i.serviceName, err)
+		}
+	}
+	// LeastConn: 这里可以实现最少连接数的选择逻辑，需要维护每个实例的连接数统计
+	return services[0]
 }
 
 // CallJSON 调用服务的JSON API
-func (i *ServiceInvoker) CallJSON(method, path string, headers map[string]string, requestBody interface{}, responseBody interface{}) error {
-	// 将请求体序列化为JSON
+func (i *ServiceInvoker) CallJSON(method, path string, headers map[string]string, requestBody interface{}, responseBody interface{}, opts ...CallOption) error {
+	return i.CallCodec(JSONCodec, method, path, headers, requestBody, responseBody, opts...)
+}
+
+// CallCodec 使用指定的编解码器调用服务的API
+func (i *ServiceInvoker) CallCodec(codec Codec, method, path string, headers map[string]string, requestBody interface{}, responseBody interface{}, opts ...CallOption) error {
+	if codec == nil {
+		return fmt.Errorf("codec cannot be nil")
+	}
+
+	// 序列化请求体
 	var bodyBytes []byte
 	var err error
 	if requestBody != nil {
-		bodyBytes, err = json.Marshal(requestBody)
+		bodyBytes, err = codec.Marshal(requestBody)
 		if err != nil {
 			return fmt.Errorf("failed to marshal request body: %v", err)
 		}
 	}
 
-	// 设置JSON请求头
-	if headers == nil {
-		headers = make(map[string]string)
-	}
-	headers["Content-Type"] = "application/json"
-	headers["Accept"] = "application/json"
-
-	// 发送请求
-	resp, err := i.Call(method, path, headers, bodyBytes)
+	// 发送请求，请求头只复制一次，并设置内容类型
+	callOpts := newCallOptions(opts)
+	h := i.withCorrelation(headers, callOpts.ctx, codec.ContentType())
+	resp, err := i.invoke(method, path, h, bodyBytes, callOpts)
 	if err != nil {
 		return err
 	}
@@ -198,12 +378,17 @@ func (i *ServiceInvoker) CallJSON(method, path string, headers map[string]string
 
 	// 检查响应状态
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("service returned error status: %s", resp.Status)
+		return i.newHTTPError(resp)
 	}
 
 	// 解析响应体
 	if responseBody != nil {
-		if err := json.NewDecoder(resp.Body).Decode(responseBody); err != nil {
+		buf := bufferPool.Get().(*bytes.Buffer)
+		defer putBuffer(buf)
+		if _, err := buf.ReadFrom(resp.Body); err != nil {
+			return fmt.Errorf("failed to read response body: %v", err)
+		}
+		if err := codec.Unmarshal(buf.Bytes(), responseBody); err != nil {
 			return fmt.Errorf("failed to decode response body: %v", err)
 		}
 	}
@@ -211,6 +396,70 @@ func (i *ServiceInvoker) CallJSON(method, path string, headers map[string]string
 	return nil
 }
 
+// bufferPool 复用读取响应体的缓冲区
+var bufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// putBuffer 归还缓冲区，过大的缓冲区直接丢弃以免长期占用内存
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > 1<<20 {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+// filterServices 根据标签和元数据过滤服务实例
+func (i *ServiceInvoker) filterServices(services []*api.ServiceEntry) []*api.ServiceEntry {
+	if len(i.tags) == 0 && len(i.metaFilter) == 0 && i.versionRange == nil && i.shadowTag == "" {
+		return services
+	}
+
+	var filtered []*api.ServiceEntry
+	for _, service := range services {
+		if len(i.tags) > 0 && !containsAll(service.Service.Tags, i.tags) {
+			continue
+		}
+		if i.shadowTag != "" && containsAll(service.Service.Tags, []string{i.shadowTag}) {
+			continue
+		}
+		if !i.matchesMeta(service.Service.Meta) {
+			continue
+		}
+		if i.versionRange != nil {
+			v, err := parseVersion(service.Service.Meta[i.versionKey])
+			if err != nil || !i.versionRange.check(v) {
+				continue
+			}
+		}
+		filtered = append(filtered, service)
+	}
+	return filtered
+}
+
+// matchesMeta 检查服务元数据是否满足元数据过滤条件
+func (i *ServiceInvoker) matchesMeta(meta map[string]string) bool {
+	for k, want := range i.metaFilter {
+		got, ok := meta[k]
+		if !ok {
+			return false
+		}
+		if got == want {
+			continue
+		}
+		c, isPattern := i.metaPatterns[k]
+		if !isPattern {
+			return false
+		}
+		v, err := parseVersion(got)
+		if err != nil || !c.check(v) {
+			return false
+		}
+	}
+	return true
+}
+
 // 辅助函数：检查数组是否包含所有指定的标签
 func containsAll(array []string, items []string) bool {
 	for _, item := range items {
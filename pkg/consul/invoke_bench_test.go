@@ -0,0 +1,71 @@
+package consul_test
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/stones-hub/taurus-pro-consul/pkg/consul"
+	"github.com/stones-hub/taurus-pro-consul/pkg/consul/consultest"
+)
+
+type benchReply struct {
+	OK    bool   `json:"ok"`
+	Value string `json:"value"`
+}
+
+// newBenchInvoker 将httptest服务器注册为echo服务的唯一实例并返回其调用器
+func newBenchInvoker(b *testing.B) *consul.ServiceInvoker {
+	b.Helper()
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"ok":true,"value":"pong"}`)
+	}))
+	b.Cleanup(backend.Close)
+
+	host, portStr, err := net.SplitHostPort(backend.Listener.Addr().String())
+	if err != nil {
+		b.Fatal(err)
+	}
+	port, _ := strconv.Atoi(portStr)
+
+	c, srv := consultest.NewFakeClient(b)
+	srv.AddService(&api.AgentService{ID: "echo-1", Service: "echo", Address: host, Port: port})
+	return c.NewServiceInvoker("echo")
+}
+
+func BenchmarkCall(b *testing.B) {
+	invoker := newBenchInvoker(b)
+	body := []byte(`{"ping":true}`)
+
+	b.ReportAllocs()
+	for b.Loop() {
+		resp, err := invoker.Call(http.MethodPost, "/echo", nil, body)
+		if err != nil {
+			b.Fatalf("Call: %v", err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+}
+
+func BenchmarkCallJSON(b *testing.B) {
+	invoker := newBenchInvoker(b)
+	req := map[string]bool{"ping": true}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		var reply benchReply
+		if err := invoker.CallJSON(http.MethodPost, "/echo", nil, req, &reply); err != nil {
+			b.Fatalf("CallJSON: %v", err)
+		}
+		if !reply.OK {
+			b.Fatal("unexpected reply")
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package consul
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// InvokerSettings 是存储在KV中的调用器设置，JSON格式，未设置的字段保持原值：
+//
+//	{"strategy": "random", "timeout": "5s", "retry_count": 2, "retry_interval": "200ms"}
+type InvokerSettings struct {
+	Strategy      string `json:"strategy,omitempty"`       // random / round_robin / least_conn / nearest_first
+	Timeout       string `json:"timeout,omitempty"`        // 每次调用尝试的超时时间
+	RetryCount    *int   `json:"retry_count,omitempty"`    // 重试次数
+	RetryInterval string `json:"retry_interval,omitempty"` // 重试间隔
+}
+
+// invokerSettings 是调用器在单次调用中使用的设置快照
+type invokerSettings struct {
+	strategy      LoadBalanceStrategy
+	timeout       time.Duration
+	retryCount    int
+	retryInterval time.Duration
+}
+
+// WithSettingsKey 从指定KV键加载调用器设置并监听变更，
+// 例如：invokers/user-service，运维人员无需重新部署即可调整负载均衡、超时和重试参数
+func WithSettingsKey(key string) InvokerOption {
+	return func(i *ServiceInvoker) {
+		i.settingsKey = key
+	}
+}
+
+// ParseStrategy 将策略名称解析为负载均衡策略
+func ParseStrategy(name string) (LoadBalanceStrategy, error) {
+	switch strings.ToLower(strings.ReplaceAll(name, "-", "_")) {
+	case "random":
+		return Random, nil
+	case "round_robin", "roundrobin":
+		return RoundRobin, nil
+	case "least_conn", "leastconn":
+		return LeastConn, nil
+	case "nearest_first", "nearestfirst":
+		return NearestFirst, nil
+	default:
+		return 0, fmt.Errorf("unknown load balance strategy: %s", name)
+	}
+}
+
+// currentSettings 返回当前设置的快照
+func (i *ServiceInvoker) currentSettings() invokerSettings {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return invokerSettings{
+		strategy:      i.strategy,
+		timeout:       i.timeout,
+		retryCount:    i.retryCount,
+		retryInterval: i.retryInterval,
+	}
+}
+
+// nextIndex 返回轮询策略的下一个序号
+func (i *ServiceInvoker) nextIndex() int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	idx := i.currentIndex
+	i.currentIndex++
+	return idx
+}
+
+// ApplySettings 校验并应用调用器设置
+func (i *ServiceInvoker) ApplySettings(s *InvokerSettings) error {
+	if s == nil {
+		return fmt.Errorf("invoker settings cannot be nil")
+	}
+
+	current := i.currentSettings()
+	var err error
+	if s.Strategy != "" {
+		if current.strategy, err = ParseStrategy(s.Strategy); err != nil {
+			return err
+		}
+	}
+	if s.Timeout != "" {
+		if current.timeout, err = time.ParseDuration(s.Timeout); err != nil {
+			return fmt.Errorf("invalid timeout: %v", err)
+		}
+	}
+	if s.RetryCount != nil {
+		if *s.RetryCount < 0 {
+			return fmt.Errorf("invalid retry count: %d", *s.RetryCount)
+		}
+		current.retryCount = *s.RetryCount
+	}
+	if s.RetryInterval != "" {
+		if current.retryInterval, err = time.ParseDuration(s.RetryInterval); err != nil {
+			return fmt.Errorf("invalid retry interval: %v", err)
+		}
+	}
+
+	i.mu.Lock()
+	i.strategy = current.strategy
+	i.timeout = current.timeout
+	i.retryCount = current.retryCount
+	i.retryInterval = current.retryInterval
+	i.mu.Unlock()
+	return nil
+}
+
+// watchSettings 加载KV中的调用器设置并在变更时重新应用
+func (i *ServiceInvoker) watchSettings() {
+	if i.settingsKey == "" {
+		return
+	}
+
+	apply := func(pair *api.KVPair) {
+		if pair == nil {
+			return
+		}
+		var s InvokerSettings
+		if err := json.Unmarshal(pair.Value, &s); err != nil {
+			i.client.logger.Printf("Error parsing invoker settings %s: %v", i.settingsKey, err)
+			return
+		}
+		if err := i.ApplySettings(&s); err != nil {
+			i.client.logger.Printf("Error applying invoker settings %s: %v", i.settingsKey, err)
+			return
+		}
+		i.client.logger.Printf("Invoker settings updated for %s from %s", i.serviceName, i.settingsKey)
+	}
+
+	// 先同步加载一次，保证调用器创建后立即生效
+	if pair, err := i.client.GetWithOptions(i.settingsKey, nil); err == nil {
+		apply(pair)
+	} else {
+		i.client.logger.Printf("Failed to load invoker settings %s: %v", i.settingsKey, err)
+	}
+
+	if _, err := i.client.WatchKey(i.settingsKey, apply); err != nil {
+		i.client.logger.Printf("Failed to watch invoker settings %s: %v", i.settingsKey, err)
+	}
+}
@@ -1,13 +1,21 @@
 package consul
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/hashicorp/consul/api"
 )
 
+// maxUpdateAttempts UpdateKey的最大CAS尝试次数
+const maxUpdateAttempts = 10
+
+// ErrUpdateConflict 表示多次CAS均因并发写入失败，更新未被应用
+var ErrUpdateConflict = errors.New("key update conflict")
+
 // Put 写入KV
-func (c *Client) Put(key string, value []byte) error {
+func (c *Client) Put(key string, value []byte, opts ...WriteOption) error {
 	if key == "" {
 		return fmt.Errorf("key cannot be empty")
 	}
@@ -17,8 +25,15 @@ func (c *Client) Put(key string, value []byte) error {
 		Value: value,
 	}
 
-	_, err := c.client.KV().Put(pair, nil)
+	w := c.kvWriteOptions(key, opts...)
+	if err := validateWrite(key, value, w); err != nil {
+		return err
+	}
+
+	c.invalidateKVCache(key)
+	_, err := c.client.KV().Put(pair, w)
 	if err != nil {
+		c.releaseKVCache(key)
 		return fmt.Errorf("failed to put value: %v", err)
 	}
 
@@ -27,12 +42,19 @@ func (c *Client) Put(key string, value []byte) error {
 }
 
 // Get 获取KV
-func (c *Client) Get(key string) ([]byte, error) {
+func (c *Client) Get(key string, opts ...QueryOption) ([]byte, error) {
 	if key == "" {
 		return nil, fmt.Errorf("key cannot be empty")
 	}
 
-	pair, _, err := c.client.KV().Get(key, nil)
+	// 带查询选项（如一致性读）时不使用缓存
+	if c.kvCache != nil && len(opts) == 0 {
+		if value, ok := c.kvCache.lookup(key); ok {
+			return value, nil
+		}
+	}
+
+	pair, _, err := c.client.KV().Get(key, c.kvQueryOptions(key, opts...))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get value: %v", err)
 	}
@@ -45,23 +67,26 @@ func (c *Client) Get(key string) ([]byte, error) {
 }
 
 // Delete 删除KV
-func (c *Client) Delete(key string) error {
+func (c *Client) Delete(key string, opts ...WriteOption) error {
 	if key == "" {
 		return fmt.Errorf("key cannot be empty")
 	}
 
-	_, err := c.client.KV().Delete(key, nil)
+	c.invalidateKVCache(key)
+	_, err := c.client.KV().Delete(key, c.kvWriteOptions(key, opts...))
 	if err != nil {
+		c.releaseKVCache(key)
 		return fmt.Errorf("failed to delete key: %v", err)
 	}
+	c.confirmKVCacheDelete(key)
 
 	c.logger.Printf("Key deleted: %s", key)
 	return nil
 }
 
 // List 列出指定前缀的所有KV
-func (c *Client) List(prefix string) (map[string][]byte, error) {
-	pairs, _, err := c.client.KV().List(prefix, nil)
+func (c *Client) List(prefix string, opts ...QueryOption) (map[string][]byte, error) {
+	pairs, _, err := c.client.KV().List(prefix, c.kvQueryOptions(prefix, opts...))
 	if err != nil {
 		return nil, fmt.Errorf("failed to list keys: %v", err)
 	}
@@ -75,7 +100,7 @@ func (c *Client) List(prefix string) (map[string][]byte, error) {
 }
 
 // CAS (Compare-And-Swap) 原子更新操作
-func (c *Client) CAS(key string, value []byte, version uint64) (bool, error) {
+func (c *Client) CAS(key string, value []byte, version uint64, opts ...WriteOption) (bool, error) {
 	if key == "" {
 		return false, fmt.Errorf("key cannot be empty")
 	}
@@ -86,7 +111,16 @@ func (c *Client) CAS(key string, value []byte, version uint64) (bool, error) {
 		ModifyIndex: version,
 	}
 
-	success, _, err := c.client.KV().CAS(pair, nil)
+	w := c.kvWriteOptions(key, opts...)
+	if err := validateWrite(key, value, w); err != nil {
+		return false, err
+	}
+
+	c.invalidateKVCache(key)
+	success, _, err := c.client.KV().CAS(pair, w)
+	if err != nil || !success {
+		c.releaseKVCache(key)
+	}
 	if err != nil {
 		return false, fmt.Errorf("failed to perform CAS operation: %v", err)
 	}
@@ -100,6 +134,59 @@ func (c *Client) CAS(key string, value []byte, version uint64) (bool, error) {
 	return success, nil
 }
 
+// UpdateFunc 根据当前值计算新值，键不存在时old为nil，返回错误时放弃更新
+type UpdateFunc func(old []byte) ([]byte, error)
+
+// UpdateKey 以Get+CAS循环安全地读取-修改-写入键，适用于计数器和共享文档。
+// 与其他写入方冲突时重新读取并调用fn，超过重试次数返回ErrUpdateConflict
+func (c *Client) UpdateKey(key string, fn UpdateFunc, opts ...WriteOption) error {
+	if key == "" {
+		return fmt.Errorf("key cannot be empty")
+	}
+	if fn == nil {
+		return fmt.Errorf("update func cannot be nil")
+	}
+
+	w := c.kvWriteOptions(key, opts...)
+	q := &api.QueryOptions{Datacenter: w.Datacenter, Token: w.Token, RequireConsistent: true}
+	backoff := c.config.backoffPolicy(50 * time.Millisecond)
+	for attempt := 0; attempt < maxUpdateAttempts; attempt++ {
+		pair, _, err := c.client.KV().Get(key, q.WithContext(w.Context()))
+		if err != nil {
+			return fmt.Errorf("failed to get value: %v", err)
+		}
+
+		var old []byte
+		var index uint64
+		if pair != nil {
+			old, index = pair.Value, pair.ModifyIndex
+		}
+		value, err := fn(old)
+		if err != nil {
+			return err
+		}
+		if err := validateWrite(key, value, w); err != nil {
+			return err
+		}
+
+		c.invalidateKVCache(key)
+		ok, _, err := c.client.KV().CAS(&api.KVPair{Key: key, Value: value, ModifyIndex: index}, w)
+		if err != nil || !ok {
+			c.releaseKVCache(key)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to perform CAS operation: %v", err)
+		}
+		if ok {
+			return nil
+		}
+		if !sleepContext(w.Context(), backoff.Delay(attempt)) {
+			return w.Context().Err()
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrUpdateConflict, key)
+}
+
 // GetWithOptions 获取KV，支持更多选项
 func (c *Client) GetWithOptions(key string, opts *api.QueryOptions) (*api.KVPair, error) {
 	if key == "" {
@@ -124,8 +211,10 @@ func (c *Client) PutWithOptions(pair *api.KVPair, opts *api.WriteOptions) error
 		return fmt.Errorf("key cannot be empty")
 	}
 
+	c.invalidateKVCache(pair.Key)
 	_, err := c.client.KV().Put(pair, opts)
 	if err != nil {
+		c.releaseKVCache(pair.Key)
 		return fmt.Errorf("failed to put value: %v", err)
 	}
 
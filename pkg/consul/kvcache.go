@@ -0,0 +1,193 @@
+package consul
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// WithKVCache 在内存中缓存prefix下的KV，由一个共享的前缀阻塞查询按ModifyIndex更新，
+// 不带查询选项的Get直接从内存返回，适合每个请求都要读取的功能开关、路由表等热点键。
+// 缓存是最终一致的，本客户端写入或删除的键在写入生效的前缀更新到达前回源读取
+func WithKVCache(prefix string) Option {
+	return func(c *Config) {
+		c.kvCache = &prefix
+	}
+}
+
+// KVCacheStats KV读缓存的统计
+type KVCacheStats struct {
+	Prefix string // 缓存的前缀
+	Synced bool   // 是否已完成首次加载
+	Keys   int    // 缓存的键数量
+	Hits   uint64 // 从缓存返回的读取次数
+	Misses uint64 // 回源读取的次数
+}
+
+// kvCache 前缀KV的内存缓存
+type kvCache struct {
+	prefix string
+
+	mu      sync.RWMutex
+	pairs   map[string]*api.KVPair // 前缀下的键值，key为完整键名
+	invalid map[string]uint64      // 本客户端写入后尚未经前缀更新确认的键，值为标记时键的ModifyIndex，不存在时为0
+	synced  bool
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// lookup 从缓存读取键，返回值的副本，调用方修改不会影响缓存；ok为false表示需要回源
+func (kc *kvCache) lookup(key string) (value []byte, ok bool) {
+	if !strings.HasPrefix(key, kc.prefix) {
+		return nil, false
+	}
+
+	kc.mu.RLock()
+	defer kc.mu.RUnlock()
+	if _, pending := kc.invalid[key]; !kc.synced || pending {
+		kc.misses.Add(1)
+		return nil, false
+	}
+	kc.hits.Add(1)
+	if pair := kc.pairs[key]; pair != nil {
+		return bytes.Clone(pair.Value), true
+	}
+	return nil, true
+}
+
+// invalidate 本客户端写入键前调用，使其回源读取直到前缀更新中该键的ModifyIndex发生变化。
+// 在写入前标记，避免写入引起的前缀更新先于标记到达而使键一直回源
+func (kc *kvCache) invalidate(key string) {
+	if !strings.HasPrefix(key, kc.prefix) {
+		return
+	}
+	kc.mu.Lock()
+	var index uint64
+	if pair := kc.pairs[key]; pair != nil {
+		index = pair.ModifyIndex
+	}
+	kc.invalid[key] = index
+	kc.mu.Unlock()
+}
+
+// release 清除未生效写入的失效标记：写入失败、CAS未成功，或删除的键在缓存中本就不存在时，
+// 前缀更新中该键的ModifyIndex不会变化，不清除标记会使键一直回源。
+// 写入在失败返回前已生效时，前缀更新会随后带来新值
+func (kc *kvCache) release(key string, deleted bool) {
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+	marked, ok := kc.invalid[key]
+	if !ok {
+		return
+	}
+	if deleted && (marked != 0 || kc.pairs[key] != nil) {
+		// 删除了缓存中存在的键，等待前缀更新确认
+		return
+	}
+	delete(kc.invalid, key)
+}
+
+// update 以前缀的最新快照替换缓存，ModifyIndex未变的键沿用原值。
+// 只清除ModifyIndex相对标记时已变化的失效标记，其他键的更新不会使尚未生效的写入被当作已确认
+func (kc *kvCache) update(pairs api.KVPairs) {
+	next := make(map[string]*api.KVPair, len(pairs))
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+	for _, pair := range pairs {
+		if old := kc.pairs[pair.Key]; old != nil && old.ModifyIndex == pair.ModifyIndex {
+			next[pair.Key] = old
+			continue
+		}
+		next[pair.Key] = pair
+	}
+	kc.pairs = next
+	for key, marked := range kc.invalid {
+		var index uint64
+		if pair := next[key]; pair != nil {
+			index = pair.ModifyIndex
+		}
+		if index != marked {
+			delete(kc.invalid, key)
+		}
+	}
+	kc.synced = true
+}
+
+// runKVCache 对缓存前缀执行阻塞查询直到客户端关闭，索引处理与watchLoop一致
+func (c *Client) runKVCache(kc *kvCache) {
+	backoff := c.config.backoffPolicy(0)
+	failures := 0
+	var waitIndex uint64
+	for c.ctx.Err() == nil {
+		q := c.kvQueryOptions(kc.prefix).WithContext(c.ctx)
+		q.WaitIndex = waitIndex
+		pairs, meta, err := c.client.KV().List(kc.prefix, q)
+		if err != nil {
+			if c.ctx.Err() != nil {
+				return
+			}
+			delay := backoff.Delay(failures)
+			failures++
+			c.logger.Printf("Error refreshing KV cache %s, retrying in %v: %v", kc.prefix, delay, err)
+			sleepContext(c.ctx, delay)
+			continue
+		}
+		failures = 0
+
+		index := meta.LastIndex
+		if index < waitIndex {
+			waitIndex = 0
+			continue
+		}
+		if index == 0 {
+			index = 1
+		}
+		waitIndex = index
+		kc.update(pairs)
+	}
+}
+
+// invalidateKVCache 本客户端写入键前调用，未启用缓存时不做任何操作
+func (c *Client) invalidateKVCache(key string) {
+	if c.kvCache != nil {
+		c.kvCache.invalidate(key)
+	}
+}
+
+// releaseKVCache 写入未生效时清除键的失效标记，未启用缓存时不做任何操作
+func (c *Client) releaseKVCache(keys ...string) {
+	if c.kvCache == nil {
+		return
+	}
+	for _, key := range keys {
+		c.kvCache.release(key, false)
+	}
+}
+
+// confirmKVCacheDelete 删除成功后调用，键在缓存中本就不存在时清除其失效标记
+func (c *Client) confirmKVCacheDelete(key string) {
+	if c.kvCache != nil {
+		c.kvCache.release(key, true)
+	}
+}
+
+// KVCacheStats 返回KV读缓存的统计，未启用WithKVCache时返回零值
+func (c *Client) KVCacheStats() KVCacheStats {
+	kc := c.kvCache
+	if kc == nil {
+		return KVCacheStats{}
+	}
+	kc.mu.RLock()
+	defer kc.mu.RUnlock()
+	return KVCacheStats{
+		Prefix: kc.prefix,
+		Synced: kc.synced,
+		Keys:   len(kc.pairs),
+		Hits:   kc.hits.Load(),
+		Misses: kc.misses.Load(),
+	}
+}
@@ -0,0 +1,110 @@
+package consul
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// WithAutoDeregisterOnExit 设置在收到SIGINT/SIGTERM信号时自动注销本客户端注册的所有服务。
+// 应用通过signal.Notify注册的处理照常收到信号；应用未处理信号时进程不会因信号退出，
+// 需配合WithExitAfterDeregister
+func WithAutoDeregisterOnExit() Option {
+	return func(c *Config) {
+		c.autoDeregister = true
+	}
+}
+
+// WithExitAfterDeregister 与WithAutoDeregisterOnExit配合使用，注销完成后恢复信号的默认处理并重新投递信号，
+// 使进程按默认行为退出。会同时移除应用通过signal.Notify注册的处理，只适用于应用自身不处理退出信号的场景
+func WithExitAfterDeregister() Option {
+	return func(c *Config) {
+		c.exitAfterDeregister = true
+	}
+}
+
+// DeregisterAll 注销通过本客户端注册的所有服务
+func (c *Client) DeregisterAll() error {
+	c.mu.RLock()
+	ids := make([]string, 0, len(c.services))
+	for id := range c.services {
+		ids = append(ids, id)
+	}
+	c.mu.RUnlock()
+
+	var lastErr error
+	for _, id := range ids {
+		if err := c.DeregisterService(id); err != nil {
+			c.logger.Printf("Failed to deregister service %s: %v", id, err)
+			lastErr = err
+		}
+	}
+
+	if lastErr != nil {
+		return fmt.Errorf("failed to deregister all services: %v", lastErr)
+	}
+	return nil
+}
+
+// Drain 优雅下线服务实例：先开启维护模式使其从健康实例列表中移除，
+// 等待wait时长让负载均衡器和调用方缓存感知后再注销，实现零丢请求的发布。客户端关闭时提前结束等待并注销
+func (c *Client) Drain(serviceID string, wait time.Duration) error {
+	if serviceID == "" {
+		return fmt.Errorf("service ID cannot be empty")
+	}
+
+	const reason = "draining before shutdown"
+	err := c.client.Agent().EnableServiceMaintenanceOpts(serviceID, reason, &api.QueryOptions{Token: c.serviceToken(serviceID)})
+	c.audit(AuditEntry{Action: AuditMaintenance, ServiceID: serviceID, Reason: reason}, err)
+	if err != nil {
+		return fmt.Errorf("failed to enable maintenance mode: %v", err)
+	}
+	c.logger.Printf("Service %s is draining, deregistering in %v", serviceID, wait)
+
+	if wait > 0 {
+		sleepContext(c.ctx, wait)
+	}
+
+	return c.DeregisterService(serviceID)
+}
+
+// RecoverAndDeregister 在发生panic时注销所有服务后继续panic，需通过defer调用：
+//
+//	defer client.RecoverAndDeregister()
+func (c *Client) RecoverAndDeregister() {
+	if r := recover(); r != nil {
+		c.logger.Printf("Panic detected, deregistering services: %v", r)
+		c.DeregisterAll()
+		panic(r)
+	}
+}
+
+// watchExitSignals 监听退出信号并注销服务
+func (c *Client) watchExitSignals() {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+
+	c.goWorker("exit signal watcher", func() {
+		defer signal.Stop(sigCh)
+
+		select {
+		case <-c.ctx.Done():
+			return
+		case sig := <-sigCh:
+			c.logger.Printf("Received signal %v, deregistering services", sig)
+			c.DeregisterAll()
+
+			if c.config.exitAfterDeregister {
+				// 恢复默认处理后重新投递信号，使进程按默认行为退出
+				signal.Reset(sig)
+				if p, err := os.FindProcess(os.Getpid()); err == nil {
+					p.Signal(sig)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,125 @@
+package consul
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// ErrInvalidConfig 表示配置未通过注册的校验规则
+var ErrInvalidConfig = errors.New("invalid config")
+
+// ConfigValidator 校验解析后的配置，config为解析目标的指针，返回错误表示配置不可用
+type ConfigValidator func(key string, config interface{}) error
+
+// ConfigRule 配置键的校验规则，监听和ValidateConfigKey共用
+type ConfigRule struct {
+	Schema     []byte            // 配置需符合的JSON Schema，为空时不校验
+	Validators []ConfigValidator // 解析后执行的业务校验，如端口范围、上下游依赖
+}
+
+// RegisterConfigRule 为键前缀注册校验规则，多个前缀匹配时所有规则都需通过。
+// 监听到未通过规则的更新会被忽略并回调OnError，已加载的配置保持不变
+func (c *Client) RegisterConfigRule(prefix string, rule ConfigRule) error {
+	if rule.Schema != nil {
+		if _, err := CompileSchema(rule.Schema); err != nil {
+			return err
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.rules == nil {
+		c.rules = make(map[string][]ConfigRule)
+	}
+	c.rules[prefix] = append(c.rules[prefix], rule)
+	return nil
+}
+
+// ValidateConfigKey 按与监听相同的规则检查候选配置而不写入：解析JSON、按注册的schema校验、
+// 解析到into并执行注册的校验函数。into为nil时解析为通用的JSON值，
+// 可在CI流水线中以服务实际使用的结构体调用，提前拦截不合规的配置变更
+func (c *Client) ValidateConfigKey(key string, candidate []byte, into interface{}) error {
+	if key == "" {
+		return fmt.Errorf("key cannot be empty")
+	}
+	var doc interface{}
+	if err := json.Unmarshal(candidate, &doc); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidConfig, key, err)
+	}
+	if into == nil {
+		into = &doc
+	}
+	return c.applyConfigRules(key, candidate, into)
+}
+
+// configRules 返回键匹配的所有前缀的校验规则，按前缀从短到长排列
+func (c *Client) configRules(key string) []ConfigRule {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var prefixes []string
+	for prefix := range c.rules {
+		if strings.HasPrefix(key, prefix) {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	slices.SortFunc(prefixes, func(a, b string) int {
+		return cmp.Or(cmp.Compare(len(a), len(b)), strings.Compare(a, b))
+	})
+
+	var rules []ConfigRule
+	for _, prefix := range prefixes {
+		rules = append(rules, c.rules[prefix]...)
+	}
+	return rules
+}
+
+// applyConfigRules 按注册的规则校验配置并解析到config。存在校验函数时先解析到config的副本，
+// 全部通过后才写回，未通过时config保持不变；副本通过JSON编解码深拷贝，不与原值共享map和切片
+func (c *Client) applyConfigRules(key string, value []byte, config interface{}) error {
+	rules := c.configRules(key)
+	var validators []ConfigValidator
+	for _, rule := range rules {
+		if rule.Schema != nil {
+			s, err := CompileSchema(rule.Schema)
+			if err != nil {
+				return err
+			}
+			if err := s.Validate(value); err != nil {
+				return fmt.Errorf("%w: %s: %v", ErrInvalidConfig, key, err)
+			}
+		}
+		validators = append(validators, rule.Validators...)
+	}
+	if len(validators) == 0 {
+		return json.Unmarshal(value, config)
+	}
+
+	v := reflect.ValueOf(config)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("config must be a non-nil pointer")
+	}
+	// 先将当前值深拷贝到副本，保留新配置中未出现的字段
+	candidate := reflect.New(v.Elem().Type())
+	current, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to copy config: %v", err)
+	}
+	if err := json.Unmarshal(current, candidate.Interface()); err != nil {
+		return fmt.Errorf("failed to copy config: %v", err)
+	}
+	if err := json.Unmarshal(value, candidate.Interface()); err != nil {
+		return err
+	}
+	for _, validate := range validators {
+		if err := validate(key, candidate.Interface()); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidConfig, key, err)
+		}
+	}
+	v.Elem().Set(candidate.Elem())
+	return nil
+}
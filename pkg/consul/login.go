@@ -0,0 +1,296 @@
+package consul
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// defaultBearerTokenFile Kubernetes服务账号Token的默认挂载路径
+const defaultBearerTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// LoginOptions 通过ACL登录接口换取Token的选项
+type LoginOptions struct {
+	AuthMethod      string            // 认证方法名称，如Kubernetes或JWT类型的auth method
+	BearerToken     string            // 工作负载身份凭据，如服务账号JWT
+	BearerTokenFile string            // 从文件读取凭据，每次登录时重新读取以获取轮换后的内容；两者都为空时使用Kubernetes服务账号Token
+	Meta            map[string]string // 附加到换取的Token上的元数据
+}
+
+// WithLogin 启动时通过认证方法登录换取ACL Token，替代配置中的静态Token；
+// Token有过期时间时在到期前重新登录续期，关闭客户端时注销。不能与WithToken同时使用，
+// 单次调用或KV前缀指定的Token仍然优先
+func WithLogin(opts LoginOptions) Option {
+	return func(c *Config) {
+		c.login = &opts
+	}
+}
+
+// loginSession 通过登录换取的当前Token
+type loginSession struct {
+	opts LoginOptions
+
+	mu    sync.RWMutex
+	token *api.ACLToken
+
+	renewMu sync.Mutex                 // 串行化Token失效后的重新登录
+	renew   func(expired string) error // Token失效后重新登录，由客户端创建后设置
+}
+
+// secret 返回当前Token的SecretID，尚未登录时返回空字符串
+func (s *loginSession) secret() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.token == nil {
+		return ""
+	}
+	return s.token.SecretID
+}
+
+// current 返回当前Token
+func (s *loginSession) current() *api.ACLToken {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.token
+}
+
+// swap 替换当前Token并返回旧Token
+func (s *loginSession) swap(token *api.ACLToken) *api.ACLToken {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old := s.token
+	s.token = token
+	return old
+}
+
+// bearerToken 返回登录使用的凭据
+func (s *loginSession) bearerToken() (string, error) {
+	if s.opts.BearerToken != "" {
+		return s.opts.BearerToken, nil
+	}
+	path := s.opts.BearerTokenFile
+	if path == "" {
+		path = defaultBearerTokenFile
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read bearer token: %v", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// loginTransport 为未携带Token的请求附加登录换取的Token，Token失效时重新登录并重试一次
+type loginTransport struct {
+	base    http.RoundTripper
+	session *loginSession
+}
+
+// RoundTrip 实现http.RoundTripper，登录请求本身不附加Token，避免携带已过期的Token
+func (t *loginTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("X-Consul-Token") != "" || req.URL.Path == "/v1/acl/login" {
+		return t.base.RoundTrip(req)
+	}
+	secret := t.session.secret()
+	if secret == "" {
+		return t.base.RoundTrip(req)
+	}
+	resp, err := t.base.RoundTrip(withToken(req, secret))
+	if err != nil || !tokenRejected(resp) || t.session.renew == nil {
+		return resp, err
+	}
+
+	if renewErr := t.session.renew(secret); renewErr != nil {
+		return resp, nil
+	}
+	replay := req
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			// 请求体无法重放，返回原响应，后续请求使用新Token
+			return resp, nil
+		}
+		body, bodyErr := req.GetBody()
+		if bodyErr != nil {
+			return resp, nil
+		}
+		replay = req.Clone(req.Context())
+		replay.Body = body
+	}
+	resp.Body.Close()
+	return t.base.RoundTrip(withToken(replay, t.session.secret()))
+}
+
+// withToken 返回附加了Token请求头的请求副本
+func withToken(req *http.Request, token string) *http.Request {
+	req = req.Clone(req.Context())
+	req.Header.Set("X-Consul-Token", token)
+	return req
+}
+
+// tokenRejected 判断响应是否表示Token已过期或被删除，Consul对此返回403和"ACL not found"，
+// 读取的响应体会被还原以便调用方继续使用
+func tokenRejected(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusForbidden {
+		return false
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+	msg := strings.ToLower(string(body))
+	return strings.Contains(msg, "acl not found") || strings.Contains(msg, "token expired") ||
+		strings.Contains(msg, "token is expired")
+}
+
+// LoginToken 返回通过WithLogin换取的当前Token，未启用登录时返回nil
+func (c *Client) LoginToken() *api.ACLToken {
+	if c.login == nil {
+		return nil
+	}
+	return c.login.current()
+}
+
+// Relogin 立即通过认证方法重新登录并替换当前Token，旧Token随后被注销
+func (c *Client) Relogin() error {
+	if c.login == nil {
+		return fmt.Errorf("login is not enabled")
+	}
+	token, err := c.doLogin()
+	if err != nil {
+		return err
+	}
+	if c.ctx.Err() != nil {
+		// 客户端已关闭，不再保留新Token
+		c.logout(token)
+		return c.ctx.Err()
+	}
+	if old := c.login.swap(token); old != nil {
+		c.logout(old)
+	}
+	return nil
+}
+
+// doLogin 通过认证方法换取Token
+func (c *Client) doLogin() (*api.ACLToken, error) {
+	bearer, err := c.login.bearerToken()
+	if err != nil {
+		return nil, err
+	}
+	params := &api.ACLLoginParams{
+		AuthMethod:  c.login.opts.AuthMethod,
+		BearerToken: bearer,
+		Meta:        c.login.opts.Meta,
+	}
+	token, _, err := c.client.ACL().Login(params, &api.WriteOptions{Datacenter: c.config.datacenter})
+	if err != nil {
+		return nil, fmt.Errorf("failed to login with auth method %s: %v", c.login.opts.AuthMethod, err)
+	}
+	return token, nil
+}
+
+// renewExpired 在Token被Consul拒绝后重新登录，并发请求只触发一次登录：
+// 当前Token已不是被拒绝的Token时说明其他请求已完成续期
+func (c *Client) renewExpired(expired string) error {
+	c.login.renewMu.Lock()
+	defer c.login.renewMu.Unlock()
+	if c.login.secret() != expired {
+		return nil
+	}
+
+	c.logger.Printf("Login token rejected by consul, logging in again via auth method %s", c.login.opts.AuthMethod)
+	if err := c.Relogin(); err != nil {
+		c.logger.Printf("Failed to renew rejected login token: %v", err)
+		c.emit(Event{Type: EventTokenRenewalFailed, Message: err.Error()})
+		return err
+	}
+	return nil
+}
+
+// renewScheduled 按计划续期Token，期间Token已因失效被重新登录替换时跳过，由调用方按新Token重新计时
+func (c *Client) renewScheduled(secret string) error {
+	c.login.renewMu.Lock()
+	defer c.login.renewMu.Unlock()
+	if c.login.secret() != secret {
+		return nil
+	}
+	if err := c.Relogin(); err != nil {
+		return err
+	}
+	c.logger.Printf("Renewed login token via auth method %s", c.login.opts.AuthMethod)
+	return nil
+}
+
+// logout 注销登录换取的Token，失败时只记录日志，Token会在过期后被Consul清理
+func (c *Client) logout(token *api.ACLToken) {
+	if _, err := c.client.ACL().Logout(&api.WriteOptions{Token: token.SecretID}); err != nil {
+		c.logger.Printf("Failed to logout token %s: %v", token.AccessorID, err)
+	}
+}
+
+// endLogin 关闭客户端时注销当前Token
+func (c *Client) endLogin() {
+	if c.login == nil {
+		return
+	}
+	if token := c.login.swap(nil); token != nil {
+		c.logout(token)
+	}
+}
+
+// runInitialLogin 启动时Consul不可用的客户端在后台按退避重试登录，成功后转入定期续期
+func (c *Client) runInitialLogin() {
+	backoff := c.config.backoffPolicy(c.config.retryTime)
+	for attempt := 0; ; attempt++ {
+		token, err := c.doLogin()
+		if err == nil {
+			if c.ctx.Err() != nil {
+				c.logout(token)
+				return
+			}
+			c.login.swap(token)
+			c.logger.Printf("Logged in via auth method %s", c.login.opts.AuthMethod)
+			break
+		}
+		c.logger.Printf("Failed to login (attempt %d): %v", attempt+1, err)
+		c.emit(Event{Type: EventTokenRenewalFailed, Message: err.Error()})
+		if !sleepContext(c.ctx, backoff.Delay(attempt)) {
+			return
+		}
+	}
+	c.runLoginRenewal()
+}
+
+// runLoginRenewal 在Token剩余有效期的2/3处重新登录续期，失败时按退避重试并发出事件，直到客户端关闭
+func (c *Client) runLoginRenewal() {
+	backoff := c.config.backoffPolicy(c.config.retryTime)
+	failures := 0
+	for {
+		token := c.login.current()
+		if token == nil || token.ExpirationTime == nil {
+			return
+		}
+		delay := time.Until(*token.ExpirationTime) * 2 / 3
+		if failures > 0 {
+			delay = backoff.Delay(failures - 1)
+		}
+		if !sleepContext(c.ctx, delay) {
+			return
+		}
+
+		if err := c.renewScheduled(token.SecretID); err != nil {
+			failures++
+			c.logger.Printf("Failed to renew login token (attempt %d): %v", failures, err)
+			c.emit(Event{Type: EventTokenRenewalFailed, Message: err.Error()})
+			continue
+		}
+		failures = 0
+	}
+}
@@ -0,0 +1,323 @@
+package consul
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// ReadPreference 多集群客户端的读取策略
+type ReadPreference int
+
+const (
+	// ReadPrimaryPreferred 优先读主集群，主集群不可用或读取失败时读备集群（默认）
+	ReadPrimaryPreferred ReadPreference = iota
+	// ReadPrimary 只读主集群，不降级
+	ReadPrimary
+	// ReadSecondaryPreferred 优先读备集群以降低主集群负载，备集群不可用或读取失败时读主集群
+	ReadSecondaryPreferred
+)
+
+// MultiClusterOption 定义多集群客户端的选项
+type MultiClusterOption func(*MultiClusterClient)
+
+// WithReadPreference 设置读取策略
+func WithReadPreference(pref ReadPreference) MultiClusterOption {
+	return func(m *MultiClusterClient) {
+		m.pref = pref
+	}
+}
+
+// WithClusterProbeInterval 设置探测各集群健康状态的间隔，默认5秒
+func WithClusterProbeInterval(interval time.Duration) MultiClusterOption {
+	return func(m *MultiClusterClient) {
+		m.interval = interval
+	}
+}
+
+// WithSwitchoverThreshold 设置判定集群不可用所需的连续探测失败次数，默认3次
+func WithSwitchoverThreshold(n int) MultiClusterOption {
+	return func(m *MultiClusterClient) {
+		m.threshold = n
+	}
+}
+
+// MultiClusterClient 面向主备两个Consul集群（不同地址或数据中心）的组合客户端：
+// 写入和注册只发往主集群，读取按ReadPreference选择集群，首选集群不可用时直接读另一个，
+// 读取失败时也会尝试另一个集群。集群健康状态由后台探测维护，连续失败达到阈值后切换，
+// 恢复后切回
+type MultiClusterClient struct {
+	primary   *Client
+	secondary *Client
+	pref      ReadPreference
+	interval  time.Duration
+	threshold int
+
+	mu     sync.RWMutex
+	health [2]clusterHealth // 主、备集群的健康状态
+
+	cancel context.CancelFunc
+}
+
+// clusterHealth 单个集群的探测结果
+type clusterHealth struct {
+	down      bool      // 是否已判定为不可用
+	failures  int       // 连续探测失败次数
+	lastError string    // 最近一次探测错误
+	changed   time.Time // 最近一次状态变化的时间
+}
+
+// ClusterStatus 单个集群的健康状态
+type ClusterStatus struct {
+	Address   string    `json:"address"`              // 集群当前使用的地址
+	Healthy   bool      `json:"healthy"`              // 是否可用
+	Failures  int       `json:"failures"`             // 连续探测失败次数
+	LastError string    `json:"last_error,omitempty"` // 最近一次探测错误
+	Since     time.Time `json:"since,omitempty"`      // 当前状态开始的时间
+}
+
+// MultiClusterStatus 多集群客户端的状态
+type MultiClusterStatus struct {
+	Primary   ClusterStatus `json:"primary"`
+	Secondary ClusterStatus `json:"secondary"`
+}
+
+// NewMultiClusterClient 以主、备集群的客户端创建组合客户端，两者需分别通过NewClient创建，
+// 生命周期由调用方管理，Close只停止健康探测
+func NewMultiClusterClient(primary, secondary *Client, opts ...MultiClusterOption) (*MultiClusterClient, error) {
+	if primary == nil || secondary == nil {
+		return nil, fmt.Errorf("primary and secondary clients are required")
+	}
+
+	m := &MultiClusterClient{
+		primary:   primary,
+		secondary: secondary,
+		interval:  5 * time.Second,
+		threshold: 3,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.threshold <= 0 {
+		m.threshold = 1
+	}
+
+	ctx, cancel := context.WithCancel(primary.ctx)
+	m.cancel = cancel
+	primary.goWorker("multi cluster probe", func() {
+		m.runProbe(ctx)
+	})
+	return m, nil
+}
+
+// Close 停止健康探测，不关闭主、备客户端
+func (m *MultiClusterClient) Close() {
+	m.cancel()
+}
+
+// Primary 返回主集群客户端，用于组合客户端未覆盖的操作
+func (m *MultiClusterClient) Primary() *Client {
+	return m.primary
+}
+
+// Secondary 返回备集群客户端
+func (m *MultiClusterClient) Secondary() *Client {
+	return m.secondary
+}
+
+// Status 返回主、备集群的健康状态
+func (m *MultiClusterClient) Status() MultiClusterStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	status := func(c *Client, h clusterHealth) ClusterStatus {
+		return ClusterStatus{
+			Address:   c.ActiveAddress(),
+			Healthy:   !h.down,
+			Failures:  h.failures,
+			LastError: h.lastError,
+			Since:     h.changed,
+		}
+	}
+	return MultiClusterStatus{
+		Primary:   status(m.primary, m.health[0]),
+		Secondary: status(m.secondary, m.health[1]),
+	}
+}
+
+// runProbe 定期探测两个集群直到ctx结束
+func (m *MultiClusterClient) runProbe(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		m.probe(0, m.primary)
+		m.probe(1, m.secondary)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// probe 探测单个集群并更新健康状态
+func (m *MultiClusterClient) probe(idx int, c *Client) {
+	err := c.Healthy()
+
+	m.mu.Lock()
+	h := &m.health[idx]
+	wasDown := h.down
+	if err != nil {
+		h.failures++
+		h.lastError = err.Error()
+		if h.failures >= m.threshold {
+			h.down = true
+		}
+	} else {
+		h.failures = 0
+		h.lastError = ""
+		h.down = false
+	}
+	down := h.down
+	if down != wasDown {
+		h.changed = time.Now()
+	}
+	m.mu.Unlock()
+
+	name := [2]string{"primary", "secondary"}[idx]
+	switch {
+	case down && !wasDown:
+		m.primary.logger.Printf("Consul %s cluster %s marked down: %v", name, c.ActiveAddress(), err)
+	case !down && wasDown:
+		m.primary.logger.Printf("Consul %s cluster %s recovered", name, c.ActiveAddress())
+	}
+}
+
+// readOrder 按读取策略和健康状态返回依次尝试的客户端
+func (m *MultiClusterClient) readOrder() []*Client {
+	if m.pref == ReadPrimary {
+		return []*Client{m.primary}
+	}
+
+	m.mu.RLock()
+	primaryDown, secondaryDown := m.health[0].down, m.health[1].down
+	m.mu.RUnlock()
+
+	preferSecondary := m.pref == ReadSecondaryPreferred
+	if preferSecondary && secondaryDown || !preferSecondary && primaryDown && !secondaryDown {
+		preferSecondary = !preferSecondary
+	}
+	if preferSecondary {
+		return []*Client{m.secondary, m.primary}
+	}
+	return []*Client{m.primary, m.secondary}
+}
+
+// read 按读取顺序执行读操作，出错时尝试下一个集群，全部失败时返回首个错误
+func read[T any](m *MultiClusterClient, fn func(c *Client) (T, error)) (T, error) {
+	var firstErr error
+	order := m.readOrder()
+	for i, c := range order {
+		result, err := fn(c)
+		if err == nil {
+			return result, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		if i < len(order)-1 {
+			m.primary.logger.Printf("Read from consul %s failed, trying next cluster: %v", c.ActiveAddress(), err)
+		}
+	}
+	var zero T
+	return zero, firstErr
+}
+
+// Put 写入主集群
+func (m *MultiClusterClient) Put(key string, value []byte, opts ...WriteOption) error {
+	return m.primary.Put(key, value, opts...)
+}
+
+// Get 按读取策略读取KV
+func (m *MultiClusterClient) Get(key string, opts ...QueryOption) ([]byte, error) {
+	return read(m, func(c *Client) ([]byte, error) {
+		return c.Get(key, opts...)
+	})
+}
+
+// Delete 删除主集群的KV
+func (m *MultiClusterClient) Delete(key string, opts ...WriteOption) error {
+	return m.primary.Delete(key, opts...)
+}
+
+// List 按读取策略列出前缀下的KV
+func (m *MultiClusterClient) List(prefix string, opts ...QueryOption) (map[string][]byte, error) {
+	return read(m, func(c *Client) (map[string][]byte, error) {
+		return c.List(prefix, opts...)
+	})
+}
+
+// CAS 在主集群执行CAS
+func (m *MultiClusterClient) CAS(key string, value []byte, version uint64, opts ...WriteOption) (bool, error) {
+	return m.primary.CAS(key, value, version, opts...)
+}
+
+// UpdateKey 在主集群执行读取-修改-写入
+func (m *MultiClusterClient) UpdateKey(key string, fn UpdateFunc, opts ...WriteOption) error {
+	return m.primary.UpdateKey(key, fn, opts...)
+}
+
+// RegisterService 在主集群注册服务
+func (m *MultiClusterClient) RegisterService(cfg *ServiceConfig, opts ...WriteOption) error {
+	return m.primary.RegisterService(cfg, opts...)
+}
+
+// DeregisterService 在主集群注销服务
+func (m *MultiClusterClient) DeregisterService(serviceID string, opts ...QueryOption) error {
+	return m.primary.DeregisterService(serviceID, opts...)
+}
+
+// GetHealthChecks 按读取策略获取服务的健康检查
+func (m *MultiClusterClient) GetHealthChecks(serviceID string, opts ...QueryOption) (api.HealthChecks, error) {
+	return read(m, func(c *Client) (api.HealthChecks, error) {
+		return c.GetHealthChecks(serviceID, opts...)
+	})
+}
+
+// GetHealthyServices 按读取策略获取健康的服务实例
+func (m *MultiClusterClient) GetHealthyServices(name string, opts ...QueryOption) ([]*api.ServiceEntry, error) {
+	return read(m, func(c *Client) ([]*api.ServiceEntry, error) {
+		return c.GetHealthyServices(name, opts...)
+	})
+}
+
+// GetService 按读取策略获取服务实例
+func (m *MultiClusterClient) GetService(name string, tag string, opts ...QueryOption) ([]*api.ServiceEntry, error) {
+	return read(m, func(c *Client) ([]*api.ServiceEntry, error) {
+		return c.GetService(name, tag, opts...)
+	})
+}
+
+// GetAllServices 按读取策略获取所有服务
+func (m *MultiClusterClient) GetAllServices(opts ...QueryOption) (map[string][]string, error) {
+	return read(m, func(c *Client) (map[string][]string, error) {
+		return c.GetAllServices(opts...)
+	})
+}
+
+// Instances 按读取策略获取健康实例
+func (m *MultiClusterClient) Instances(name string, tags []string, opts ...QueryOption) ([]Instance, error) {
+	return read(m, func(c *Client) ([]Instance, error) {
+		return c.Instances(name, tags, opts...)
+	})
+}
+
+var (
+	_ KVStore    = (*MultiClusterClient)(nil)
+	_ Registrar  = (*MultiClusterClient)(nil)
+	_ HealthAPI  = (*MultiClusterClient)(nil)
+	_ Discoverer = (*MultiClusterClient)(nil)
+)
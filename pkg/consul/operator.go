@@ -0,0 +1,100 @@
+package consul
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// ClusterHealth 汇总Raft成员与Autopilot健康信息，便于管理后台展示
+type ClusterHealth struct {
+	Healthy          bool             `json:"healthy"`           // 集群是否健康
+	FailureTolerance int              `json:"failure_tolerance"` // 可容忍失效的服务器数量
+	Leader           string           `json:"leader"`            // 领导者节点名称
+	Servers          []*ServerSummary `json:"servers"`           // 服务器列表
+}
+
+// ServerSummary 描述单个Consul服务器的状态
+type ServerSummary struct {
+	ID      string `json:"id"`      // Raft ID
+	Node    string `json:"node"`    // 节点名称
+	Address string `json:"address"` // Raft地址
+	Leader  bool   `json:"leader"`  // 是否为领导者
+	Voter   bool   `json:"voter"`   // 是否有投票权
+	Healthy bool   `json:"healthy"` // Autopilot健康状态
+}
+
+// RaftPeers 获取Raft集群成员
+func (c *Client) RaftPeers() ([]*api.RaftServer, error) {
+	cfg, err := c.client.Operator().RaftGetConfiguration(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get raft configuration: %v", err)
+	}
+	return cfg.Servers, nil
+}
+
+// AutopilotHealth 获取Autopilot服务器健康状态
+func (c *Client) AutopilotHealth() (*api.OperatorHealthReply, error) {
+	health, err := c.client.Operator().AutopilotServerHealth(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get autopilot health: %v", err)
+	}
+	return health, nil
+}
+
+// AutopilotState 获取Autopilot状态
+func (c *Client) AutopilotState() (*api.AutopilotState, error) {
+	state, err := c.client.Operator().AutopilotState(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get autopilot state: %v", err)
+	}
+	return state, nil
+}
+
+// KeyringList 列出gossip加密密钥的安装情况
+func (c *Client) KeyringList() ([]*api.KeyringResponse, error) {
+	keys, err := c.client.Operator().KeyringList(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keyring: %v", err)
+	}
+	return keys, nil
+}
+
+// ClusterHealth 获取集群健康汇总信息
+func (c *Client) ClusterHealth() (*ClusterHealth, error) {
+	peers, err := c.RaftPeers()
+	if err != nil {
+		return nil, err
+	}
+
+	health, err := c.AutopilotHealth()
+	if err != nil {
+		return nil, err
+	}
+
+	healthy := make(map[string]bool, len(health.Servers))
+	for _, s := range health.Servers {
+		healthy[s.ID] = s.Healthy
+	}
+
+	result := &ClusterHealth{
+		Healthy:          health.Healthy,
+		FailureTolerance: health.FailureTolerance,
+		Servers:          make([]*ServerSummary, 0, len(peers)),
+	}
+	for _, p := range peers {
+		if p.Leader {
+			result.Leader = p.Node
+		}
+		result.Servers = append(result.Servers, &ServerSummary{
+			ID:      p.ID,
+			Node:    p.Node,
+			Address: p.Address,
+			Leader:  p.Leader,
+			Voter:   p.Voter,
+			Healthy: healthy[p.ID],
+		})
+	}
+
+	return result, nil
+}
@@ -0,0 +1,55 @@
+package consul
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// PatchConfig 以RFC 7386 JSON Merge Patch局部更新JSON配置，例如只翻转一个功能开关：
+//
+//	client.PatchConfig("config/flags", []byte(`{"new_checkout": true, "legacy": null}`))
+//
+// 基于UpdateKey的读取-合并-CAS循环，与其他写入方并发时自动重试，键不存在时按空文档创建
+func (c *Client) PatchConfig(key string, patch []byte, opts ...WriteOption) error {
+	if key == "" {
+		return fmt.Errorf("key cannot be empty")
+	}
+	var p interface{}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return fmt.Errorf("invalid merge patch: %v", err)
+	}
+
+	return c.UpdateKey(key, func(old []byte) ([]byte, error) {
+		var doc interface{}
+		if len(old) > 0 {
+			if err := json.Unmarshal(old, &doc); err != nil {
+				return nil, fmt.Errorf("failed to parse config %s: %v", key, err)
+			}
+		}
+		value, err := json.Marshal(mergePatch(doc, p))
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode patched config: %v", err)
+		}
+		return value, nil
+	}, opts...)
+}
+
+// mergePatch 按RFC 7386将patch合并到target：对象递归合并，null表示删除字段，其他值直接替换
+func mergePatch(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = make(map[string]interface{})
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+			continue
+		}
+		t[k] = mergePatch(t[k], v)
+	}
+	return t
+}
@@ -0,0 +1,61 @@
+// prewarm.go
+package consul
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// PrewarmServices 预加载并持续监听下游服务的健康实例列表，
+// 服务调用器（未设置WithFilter时）直接使用缓存，启动后的首次调用无需等待服务发现。
+// 初始加载失败的服务仍会被监听，监听在客户端关闭时停止
+func (c *Client) PrewarmServices(names []string) error {
+	var firstErr error
+	for _, name := range names {
+		c.mu.RLock()
+		_, exists := c.prewarmed[name]
+		c.mu.RUnlock()
+		if exists {
+			continue
+		}
+
+		entries, err := c.GetHealthyServices(name)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to prewarm service %s: %v", name, err)
+			}
+		} else {
+			c.setPrewarmed(name, entries)
+		}
+
+		if _, err := c.WatchService(name, true, func(entries []*api.ServiceEntry) {
+			c.setPrewarmed(name, entries)
+		}); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to watch service %s: %v", name, err)
+		}
+	}
+	return firstErr
+}
+
+// setPrewarmed 更新预加载的实例列表
+func (c *Client) setPrewarmed(name string, entries []*api.ServiceEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.prewarmed == nil {
+		c.prewarmed = make(map[string][]*api.ServiceEntry)
+	}
+	c.prewarmed[name] = entries
+}
+
+// prewarmedServices 返回预加载的实例列表副本，服务未预加载时返回false
+func (c *Client) prewarmedServices(name string) ([]*api.ServiceEntry, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	entries, ok := c.prewarmed[name]
+	if !ok {
+		return nil, false
+	}
+	return slices.Clone(entries), true
+}
@@ -0,0 +1,116 @@
+package consul
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// HealthStatus 描述客户端当前的健康状态，用于就绪探针
+type HealthStatus struct {
+	Status   string        `json:"status"`          // ok 或 unavailable
+	Error    string        `json:"error,omitempty"` // 不健康时的错误信息
+	Services []string      `json:"services"`        // 通过本客户端注册的服务ID
+	Watches  []WatchStatus `json:"watches"`         // 活跃的配置监听
+}
+
+// WatchStatus 描述单个配置监听的状态
+type WatchStatus struct {
+	Key     string    `json:"key"`     // 监听的KV键
+	Started time.Time `json:"started"` // 监听启动时间
+
+	LastSuccess         time.Time `json:"last_success,omitempty"` // 最近一次成功查询的时间
+	ConsecutiveFailures int       `json:"consecutive_failures"`   // 连续失败次数
+	LastError           string    `json:"last_error,omitempty"`   // 最近一次错误
+
+	Age        time.Duration `json:"age"`                   // 监听已运行的时长
+	LastIndex  uint64        `json:"last_index"`            // 最近一次查询的索引
+	LastUpdate time.Time     `json:"last_update,omitempty"` // 最近一次收到变更的时间
+	Updates    uint64        `json:"updates"`               // 收到的变更次数
+	Errors     uint64        `json:"errors"`                // 累计错误次数
+	Resyncs    uint64        `json:"resyncs"`               // 全量重新读取的次数
+	Paused     bool          `json:"paused"`                // 是否已通过PauseWatch暂停
+}
+
+// Healthy 检查客户端到Consul的连通性，返回nil表示服务发现可用
+func (c *Client) Healthy() error {
+	select {
+	case <-c.ctx.Done():
+		return fmt.Errorf("consul client is closed")
+	default:
+	}
+
+	leader, err := c.client.Status().Leader()
+	if err != nil {
+		return fmt.Errorf("failed to reach consul: %v", err)
+	}
+	if leader == "" {
+		return fmt.Errorf("consul cluster has no leader")
+	}
+	return nil
+}
+
+// HealthStatus 返回客户端的健康状态快照
+func (c *Client) HealthStatus() *HealthStatus {
+	status := &HealthStatus{Status: "ok"}
+	if err := c.Healthy(); err != nil {
+		status.Status = "unavailable"
+		status.Error = err.Error()
+	}
+
+	c.mu.RLock()
+	status.Services = make([]string, 0, len(c.services))
+	for id := range c.services {
+		status.Services = append(status.Services, id)
+	}
+	c.mu.RUnlock()
+	status.Watches = c.WatchStatus()
+
+	sort.Strings(status.Services)
+	return status
+}
+
+// WatchStatus 返回所有活跃配置监听的状态，按键排序，
+// 可据此在连续失败次数过多或长时间未成功时告警
+func (c *Client) WatchStatus() []WatchStatus {
+	c.mu.RLock()
+	watches := make([]WatchStatus, 0, len(c.watches))
+	for _, w := range c.watches {
+		watches = append(watches, WatchStatus{
+			Key:                 w.key,
+			Started:             w.started,
+			LastSuccess:         w.lastSuccess,
+			ConsecutiveFailures: w.failures,
+			LastError:           w.lastError,
+			Age:                 time.Since(w.started),
+			LastIndex:           w.lastIndex,
+			LastUpdate:          w.lastUpdate,
+			Updates:             w.updates,
+			Errors:              w.errors,
+			Resyncs:             w.resyncs,
+			Paused:              w.paused,
+		})
+	}
+	c.mu.RUnlock()
+
+	sort.Slice(watches, func(i, j int) bool {
+		return watches[i].Key < watches[j].Key
+	})
+	return watches
+}
+
+// HealthHandler 返回报告客户端健康状态的http.Handler，
+// Consul可用时返回200，否则返回503，可直接用作Kubernetes就绪探针
+func HealthHandler(client *Client) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := client.HealthStatus()
+
+		w.Header().Set("Content-Type", "application/json")
+		if status.Status != "ok" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(status)
+	})
+}
@@ -0,0 +1,72 @@
+package consul
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// maxTxnOps Consul单个事务允许的最大操作数
+const maxTxnOps = 64
+
+// PromoteFunc 复制前改写配置值，key为相对键名，返回false时跳过该键
+type PromoteFunc func(key string, value []byte) ([]byte, bool)
+
+// PromotePrefix 将src前缀下的配置树复制到dst前缀，用于在环境间晋升配置，
+// transform可改写值（如替换主机名、凭据占位符）或跳过键，为nil时原样复制。
+// 写入按64个键一批通过事务提交，每批原子生效，某批失败时返回已写入的键数和错误；
+// dst下在src中不存在的键保持不变
+func (c *Client) PromotePrefix(src, dst string, transform PromoteFunc, opts ...WriteOption) (int, error) {
+	if src == dst {
+		return 0, fmt.Errorf("source and destination prefixes must differ")
+	}
+
+	pairs, err := c.List(src)
+	if err != nil {
+		return 0, err
+	}
+
+	var ops api.TxnOps
+	for _, key := range slices.Sorted(maps.Keys(pairs)) {
+		rel := strings.TrimPrefix(key, src)
+		if rel == "" {
+			continue
+		}
+		value := pairs[key]
+		if transform != nil {
+			var keep bool
+			if value, keep = transform(rel, value); !keep {
+				continue
+			}
+		}
+		ops = append(ops, &api.TxnOp{KV: &api.KVTxnOp{Verb: api.KVSet, Key: dst + rel, Value: value}})
+	}
+
+	w := c.kvWriteOptions(dst, opts...)
+	q := &api.QueryOptions{Datacenter: w.Datacenter, Token: w.Token}
+	written := 0
+	for batch := range slices.Chunk(ops, maxTxnOps) {
+		for _, op := range batch {
+			c.invalidateKVCache(op.KV.Key)
+		}
+		ok, resp, _, err := c.client.Txn().Txn(batch, q.WithContext(w.Context()))
+		if err != nil || !ok {
+			for _, op := range batch {
+				c.releaseKVCache(op.KV.Key)
+			}
+		}
+		if err != nil {
+			return written, fmt.Errorf("failed to promote %s to %s: %v", src, dst, err)
+		}
+		if !ok {
+			return written, fmt.Errorf("failed to promote %s to %s: %s", src, dst, txnErrors(resp))
+		}
+		written += len(batch)
+	}
+
+	c.logger.Printf("Promoted %d keys from %s to %s", written, src, dst)
+	return written, nil
+}
@@ -0,0 +1,167 @@
+package consul
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PrometheusSDConfig Prometheus服务发现输出的配置
+type PrometheusSDConfig struct {
+	Services []string      // 需要输出的服务名，为空时输出目录中的所有服务
+	Tags     []string      // 只输出同时带有这些标签的实例
+	File     string        // file_sd文件路径，Start时定期写入
+	Interval time.Duration // 定期写入的间隔，默认30秒
+}
+
+// PrometheusTargetGroup Prometheus file_sd/http_sd的目标组，每个实例一组，
+// 标签与Prometheus内置consul_sd_configs的__meta_consul_*保持一致，可复用已有的relabel规则
+type PrometheusTargetGroup struct {
+	Targets []string          `json:"targets"`
+	Labels  map[string]string `json:"labels"`
+}
+
+// PrometheusSD 将Consul中健康的服务实例输出为Prometheus服务发现格式，
+// 无需额外部署发现组件。既可定期写入file_sd文件，也可作为http_sd端点提供
+type PrometheusSD struct {
+	client *Client
+	config PrometheusSDConfig
+
+	mu      sync.Mutex
+	stopCh  chan struct{}
+	running bool
+}
+
+// NewPrometheusSD 创建Prometheus服务发现输出
+func (c *Client) NewPrometheusSD(config PrometheusSDConfig) *PrometheusSD {
+	if config.Interval <= 0 {
+		config.Interval = 30 * time.Second
+	}
+	return &PrometheusSD{client: c, config: config}
+}
+
+// Targets 返回当前通过健康检查且带有全部指定标签的实例
+func (p *PrometheusSD) Targets() ([]*PrometheusTargetGroup, error) {
+	names := p.config.Services
+	if len(names) == 0 {
+		services, err := p.client.GetAllServices()
+		if err != nil {
+			return nil, err
+		}
+		names = slices.Sorted(maps.Keys(services))
+	}
+
+	groups := make([]*PrometheusTargetGroup, 0)
+	for _, name := range names {
+		entries, _, err := p.client.client.Health().ServiceMultipleTags(name, p.config.Tags, true, p.client.queryOptions())
+		if err != nil {
+			return nil, fmt.Errorf("failed to get service %s: %v", name, err)
+		}
+		for _, entry := range entries {
+			inst := newInstance(entry)
+			labels := map[string]string{
+				"__meta_consul_service":         inst.Service,
+				"__meta_consul_service_id":      inst.ID,
+				"__meta_consul_service_address": inst.Address,
+				"__meta_consul_service_port":    fmt.Sprint(inst.Port),
+				"__meta_consul_tags":            "," + strings.Join(inst.Tags, ",") + ",",
+				"__meta_consul_health":          inst.Health,
+				"__meta_consul_dc":              inst.DC,
+			}
+			if entry.Node != nil {
+				labels["__meta_consul_node"] = entry.Node.Node
+				labels["__meta_consul_address"] = entry.Node.Address
+			}
+			for k, v := range inst.Meta {
+				labels["__meta_consul_service_metadata_"+sanitizeLabelName(k)] = v
+			}
+			groups = append(groups, &PrometheusTargetGroup{Targets: []string{inst.Addr()}, Labels: labels})
+		}
+	}
+	return groups, nil
+}
+
+// WriteFile 立即将目标写入file_sd文件，文件以原子替换的方式更新
+func (p *PrometheusSD) WriteFile() error {
+	if p.config.File == "" {
+		return fmt.Errorf("file_sd path cannot be empty")
+	}
+	groups, err := p.Targets()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(groups, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal targets: %v", err)
+	}
+	if err := writeFileAtomic(p.config.File, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", p.config.File, err)
+	}
+	return nil
+}
+
+// ServeHTTP 以Prometheus http_sd格式返回目标
+func (p *PrometheusSD) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	groups, err := p.Targets()
+	if err != nil {
+		p.client.logger.Printf("Failed to build Prometheus targets: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(groups)
+}
+
+// Start 启动后台定期写入file_sd文件
+func (p *PrometheusSD) Start() {
+	p.mu.Lock()
+	if p.running {
+		p.mu.Unlock()
+		return
+	}
+	p.running = true
+	p.stopCh = make(chan struct{})
+	stopCh := p.stopCh
+	p.mu.Unlock()
+
+	p.client.goWorker("prometheus sd", func() {
+		ticker := time.NewTicker(p.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			if err := p.WriteFile(); err != nil {
+				p.client.logger.Printf("Failed to write Prometheus file_sd: %v", err)
+			}
+			select {
+			case <-p.client.ctx.Done():
+				return
+			case <-stopCh:
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// Stop 停止后台写入
+func (p *PrometheusSD) Stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.running {
+		close(p.stopCh)
+		p.running = false
+	}
+}
+
+var invalidLabelChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// sanitizeLabelName 将元数据键转换为合法的Prometheus标签名
+func sanitizeLabelName(name string) string {
+	return invalidLabelChars.ReplaceAllString(name, "_")
+}
@@ -0,0 +1,111 @@
+package consul
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/hashicorp/consul/api"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigProvider 将单个KV键适配为viper/koanf等配置框架的数据源，本包不依赖这些框架：
+//
+//	koanf：直接作为koanf.Provider使用，k.Load(p, yaml.Parser())，并通过p.Watch重新加载
+//	viper：v.ReadConfig(p.Reader())，并在p.Watch回调中再次调用
+type ConfigProvider struct {
+	client *Client
+	key    string
+
+	mu     sync.Mutex
+	handle *WatchHandle
+	index  uint64 // 最近一次读取到的ModifyIndex
+}
+
+// ConfigProvider 创建KV键对应的配置数据源
+func (c *Client) ConfigProvider(key string) *ConfigProvider {
+	return &ConfigProvider{client: c, key: key}
+}
+
+// ReadBytes 读取键的原始内容，由调用方指定的解析器解析，键不存在时返回错误
+func (p *ConfigProvider) ReadBytes() ([]byte, error) {
+	if p.key == "" {
+		return nil, fmt.Errorf("key cannot be empty")
+	}
+	pair, _, err := p.client.client.KV().Get(p.key, p.client.kvQueryOptions(p.key))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config %s: %v", p.key, err)
+	}
+	if pair == nil {
+		return nil, fmt.Errorf("config %s not found", p.key)
+	}
+	p.mu.Lock()
+	p.index = pair.ModifyIndex
+	p.mu.Unlock()
+	return pair.Value, nil
+}
+
+// Read 读取键的内容并按YAML（兼容JSON）解析为嵌套map
+func (p *ConfigProvider) Read() (map[string]interface{}, error) {
+	data, err := p.ReadBytes()
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %v", p.key, err)
+	}
+	return values, nil
+}
+
+// Reader 以io.Reader返回键的内容，供viper.ReadConfig使用
+func (p *ConfigProvider) Reader() (io.Reader, error) {
+	data, err := p.ReadBytes()
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(data), nil
+}
+
+// Watch 监听键的变化，变化时调用cb，event为最新的原始内容，键被删除时err不为nil。
+// 签名与koanf的Watch一致，每个数据源只能监听一次
+func (p *ConfigProvider) Watch(cb func(event interface{}, err error)) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.handle != nil {
+		return fmt.Errorf("config %s is already being watched", p.key)
+	}
+
+	// watch plan首次回调为当前值，与最近一次读取的版本相同时不通知
+	lastIndex := p.index
+	h, err := p.client.WatchKey(p.key, func(pair *api.KVPair) {
+		if pair == nil {
+			if lastIndex != 0 {
+				cb(nil, fmt.Errorf("config %s was deleted", p.key))
+			}
+			lastIndex = 0
+			return
+		}
+		if pair.ModifyIndex != lastIndex {
+			lastIndex = pair.ModifyIndex
+			cb(pair.Value, nil)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	p.handle = h
+	return nil
+}
+
+// Unwatch 停止监听
+func (p *ConfigProvider) Unwatch() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.handle != nil {
+		p.handle.Stop()
+		p.handle = nil
+	}
+	return nil
+}
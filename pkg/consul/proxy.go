@@ -0,0 +1,315 @@
+package consul
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RouteTable 网关路由表，key为路径前缀，value为对应服务的调用器，最长前缀优先匹配
+type RouteTable map[string]*ServiceInvoker
+
+// Proxy 基于服务调用器的简易API网关，复用调用器的实例选择、负载均衡、重试和降级逻辑
+type Proxy struct {
+	routes        RouteTable
+	stripPrefix   bool
+	flushInterval time.Duration
+	logger        *log.Logger
+	maxBodySize   int64 // 请求体大小上限，0表示不限制
+	bufferSize    int64 // 缓存请求体以便重试的大小上限，超过时流式转发
+}
+
+// defaultProxyBufferSize 网关默认缓存请求体的大小上限
+const defaultProxyBufferSize = 1 << 20
+
+// ProxyOption 定义网关选项
+type ProxyOption func(*Proxy)
+
+// WithStripPrefix 转发前去掉匹配的路径前缀，例如"/users/"路由下的"/users/info"转发为"/info"
+func WithStripPrefix() ProxyOption {
+	return func(p *Proxy) {
+		p.stripPrefix = true
+	}
+}
+
+// WithFlushInterval 设置转发响应体时的刷新间隔，负数表示每次写入后立即刷新，
+// 默认不定期刷新，text/event-stream响应总是立即刷新
+func WithFlushInterval(interval time.Duration) ProxyOption {
+	return func(p *Proxy) {
+		p.flushInterval = interval
+	}
+}
+
+// WithMaxRequestBody 设置请求体大小上限，超过时返回413，默认不限制
+func WithMaxRequestBody(size int64) ProxyOption {
+	return func(p *Proxy) {
+		p.maxBodySize = size
+	}
+}
+
+// WithRetryBufferSize 设置缓存请求体的大小上限，不超过该大小的请求体整体缓存，失败时可按调用器设置重试；
+// 更大的请求体边读边转发，不占用内存但失败时不重试。默认1MB
+func WithRetryBufferSize(size int64) ProxyOption {
+	return func(p *Proxy) {
+		p.bufferSize = size
+	}
+}
+
+// WithProxyLogger 设置网关的日志器
+func WithProxyLogger(logger *log.Logger) ProxyOption {
+	return func(p *Proxy) {
+		p.logger = logger
+	}
+}
+
+// hopHeaders 逐跳请求头，不应被代理转发
+var hopHeaders = []string{
+	"Connection",
+	"Proxy-Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// NewProxy 根据路由表创建网关，返回的Proxy实现http.Handler
+func NewProxy(routes RouteTable, opts ...ProxyOption) *Proxy {
+	p := &Proxy{routes: routes, logger: log.Default(), bufferSize: defaultProxyBufferSize}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// ServeHTTP 将请求转发到匹配路由的服务，未匹配时返回404，调用失败时返回502。
+// 请求体不超过WithRetryBufferSize时整体缓存以便重试，否则流式转发；超过WithMaxRequestBody时返回413。
+// 响应体以流式转发，WebSocket等协议升级请求会在升级成功后双向透传
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	prefix, invoker := p.match(r.URL.Path)
+	if invoker == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	src := r.Body
+	if p.maxBodySize > 0 {
+		src = http.MaxBytesReader(w, r.Body, p.maxBodySize)
+	}
+	body, err := io.ReadAll(io.LimitReader(src, p.bufferSize+1))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+	callOpts := []CallOption{WithCallContext(r.Context()), WithStreaming()}
+	var streamed *proxyBody
+	if int64(len(body)) > p.bufferSize {
+		// 超过缓存上限，已读取的部分与剩余请求体一起流式转发
+		streamed = &proxyBody{r: io.MultiReader(bytes.NewReader(body), src)}
+		callOpts = append(callOpts, WithRequestBody(streamed))
+		body = nil
+	}
+
+	path := r.URL.Path
+	if p.stripPrefix {
+		path = "/" + strings.TrimLeft(strings.TrimPrefix(path, prefix), "/")
+	}
+	if r.URL.RawQuery != "" {
+		path += "?" + r.URL.RawQuery
+	}
+
+	resp, err := invoker.Call(r.Method, path, forwardHeaders(r), body, callOpts...)
+	if err != nil {
+		p.logger.Printf("Proxy %s %s to %s failed: %v", r.Method, r.URL.Path, invoker.serviceName, err)
+		var tooLarge *http.MaxBytesError
+		if streamed != nil && errors.As(streamed.err, &tooLarge) {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusSwitchingProtocols {
+		p.switchProtocols(w, resp)
+		return
+	}
+
+	for k, v := range resp.Header {
+		w.Header()[k] = v
+	}
+	for _, h := range hopHeaders {
+		w.Header().Del(h)
+	}
+	w.WriteHeader(resp.StatusCode)
+
+	interval := p.flushInterval
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == "text/event-stream" {
+		interval = -1
+	}
+	if err := copyResponse(w, resp.Body, interval); err != nil {
+		p.logger.Printf("Proxy %s %s response copy interrupted: %v", r.Method, r.URL.Path, err)
+	}
+}
+
+// proxyBody 流式转发的请求体，记录读取错误以便区分请求体超限和上游失败
+type proxyBody struct {
+	r   io.Reader
+	err error
+}
+
+// Read 实现io.Reader
+func (b *proxyBody) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	if err != nil && err != io.EOF {
+		b.err = err
+	}
+	return n, err
+}
+
+// switchProtocols 接管客户端连接，将101响应写回后在两端之间双向复制数据
+func (p *Proxy) switchProtocols(w http.ResponseWriter, resp *http.Response) {
+	upstream, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok {
+		http.Error(w, "upstream does not support protocol switch", http.StatusBadGateway)
+		return
+	}
+	conn, brw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		http.Error(w, "protocol switch not supported", http.StatusInternalServerError)
+		return
+	}
+	defer conn.Close()
+
+	fmt.Fprintf(brw, "HTTP/1.1 101 Switching Protocols\r\n")
+	resp.Header.Write(brw)
+	brw.WriteString("\r\n")
+	if err := brw.Flush(); err != nil {
+		return
+	}
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(upstream, brw)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(conn, upstream)
+		done <- struct{}{}
+	}()
+	<-done
+}
+
+// copyResponse 转发响应体，interval为负数时每次写入后刷新，为正数时定期刷新
+func copyResponse(w http.ResponseWriter, body io.Reader, interval time.Duration) error {
+	if interval == 0 {
+		_, err := io.Copy(w, body)
+		return err
+	}
+
+	rc := http.NewResponseController(w)
+	var mu sync.Mutex
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		stop := make(chan struct{})
+		defer close(stop)
+		go func() {
+			for {
+				select {
+				case <-stop:
+					return
+				case <-ticker.C:
+					mu.Lock()
+					rc.Flush()
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := body.Read(buf)
+		if n > 0 {
+			mu.Lock()
+			_, werr := w.Write(buf[:n])
+			if werr == nil && interval < 0 {
+				werr = rc.Flush()
+			}
+			mu.Unlock()
+			if werr != nil {
+				return werr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// match 返回最长匹配的路由前缀及其调用器
+func (p *Proxy) match(path string) (string, *ServiceInvoker) {
+	var prefix string
+	var invoker *ServiceInvoker
+	for pre, inv := range p.routes {
+		if strings.HasPrefix(path, pre) && len(pre) > len(prefix) {
+			prefix, invoker = pre, inv
+		}
+	}
+	return prefix, invoker
+}
+
+// forwardHeaders 复制请求头并添加X-Forwarded-*，多值请求头合并为一个值，
+// 协议升级请求保留Connection和Upgrade
+func forwardHeaders(r *http.Request) map[string]string {
+	upgrade := isUpgrade(r.Header)
+	headers := make(map[string]string, len(r.Header)+3)
+	for k, v := range r.Header {
+		sep := ", "
+		if k == "Cookie" {
+			sep = "; "
+		}
+		headers[k] = strings.Join(v, sep)
+	}
+	for _, h := range hopHeaders {
+		delete(headers, h)
+	}
+	if upgrade {
+		headers["Connection"] = "Upgrade"
+		headers["Upgrade"] = r.Header.Get("Upgrade")
+	}
+
+	if ip, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		if prior, ok := headers["X-Forwarded-For"]; ok {
+			ip = prior + ", " + ip
+		}
+		headers["X-Forwarded-For"] = ip
+	}
+	headers["X-Forwarded-Host"] = r.Host
+	proto := "http"
+	if r.TLS != nil {
+		proto = "https"
+	}
+	headers["X-Forwarded-Proto"] = proto
+	return headers
+}
@@ -0,0 +1,132 @@
+package consul
+
+import (
+	"context"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// ConsistencyMode 定义查询的一致性模式
+type ConsistencyMode int
+
+const (
+	// ConsistencyDefault 默认模式，由leader响应但不做额外的一致性确认
+	ConsistencyDefault ConsistencyMode = iota
+	// ConsistencyConsistent 强一致模式，leader确认自身仍为leader后再响应
+	ConsistencyConsistent
+	// ConsistencyStale 允许任意服务器响应，可能读到过期数据，但能显著降低leader负载
+	ConsistencyStale
+)
+
+// WithConsistencyMode 设置查询默认的一致性模式
+func WithConsistencyMode(mode ConsistencyMode) Option {
+	return func(c *Config) {
+		c.consistency = mode
+	}
+}
+
+// QueryOption 定义单次查询的选项，会覆盖客户端级别的设置
+type QueryOption func(*api.QueryOptions)
+
+// WithQueryConsistency 设置单次查询的一致性模式
+func WithQueryConsistency(mode ConsistencyMode) QueryOption {
+	return func(q *api.QueryOptions) {
+		applyConsistency(q, mode)
+	}
+}
+
+// WithQueryDatacenter 设置单次查询的数据中心
+func WithQueryDatacenter(datacenter string) QueryOption {
+	return func(q *api.QueryOptions) {
+		q.Datacenter = datacenter
+	}
+}
+
+// WithQueryNear 设置按距离排序的参考节点，"_agent"表示本地Agent所在节点
+func WithQueryNear(node string) QueryOption {
+	return func(q *api.QueryOptions) {
+		q.Near = node
+	}
+}
+
+// WithQueryNodeMeta 按节点元数据过滤查询结果
+func WithQueryNodeMeta(meta map[string]string) QueryOption {
+	return func(q *api.QueryOptions) {
+		q.NodeMeta = meta
+	}
+}
+
+// WithQueryFilter 设置Consul过滤表达式，例如：Service.Meta.version == "2.0"
+func WithQueryFilter(filter string) QueryOption {
+	return func(q *api.QueryOptions) {
+		q.Filter = filter
+	}
+}
+
+// WithQueryToken 设置单次查询使用的ACL Token
+func WithQueryToken(token string) QueryOption {
+	return func(q *api.QueryOptions) {
+		q.Token = token
+	}
+}
+
+// WithQueryContext 设置单次查询的上下文，用于取消或超时控制
+func WithQueryContext(ctx context.Context) QueryOption {
+	return func(q *api.QueryOptions) {
+		*q = *q.WithContext(ctx)
+	}
+}
+
+// WriteOption 定义单次写操作的选项
+type WriteOption func(*api.WriteOptions)
+
+// WithWriteDatacenter 设置单次写操作的数据中心
+func WithWriteDatacenter(datacenter string) WriteOption {
+	return func(w *api.WriteOptions) {
+		w.Datacenter = datacenter
+	}
+}
+
+// WithWriteToken 设置单次写操作使用的ACL Token
+func WithWriteToken(token string) WriteOption {
+	return func(w *api.WriteOptions) {
+		w.Token = token
+	}
+}
+
+// WithWriteContext 设置单次写操作的上下文，用于取消或超时控制
+func WithWriteContext(ctx context.Context) WriteOption {
+	return func(w *api.WriteOptions) {
+		*w = *w.WithContext(ctx)
+	}
+}
+
+// queryOptions 根据客户端配置和单次查询选项构造QueryOptions
+func (c *Client) queryOptions(opts ...QueryOption) *api.QueryOptions {
+	q := &api.QueryOptions{}
+	applyConsistency(q, c.config.consistency)
+	applyAgentCache(q, c.config.agentCache)
+	for _, opt := range opts {
+		opt(q)
+	}
+	// Agent不接受同时要求强一致和使用缓存的查询
+	if q.RequireConsistent {
+		q.UseCache = false
+	}
+	return q
+}
+
+// applyConsistency 将一致性模式写入QueryOptions
+func applyConsistency(q *api.QueryOptions, mode ConsistencyMode) {
+	q.AllowStale = mode == ConsistencyStale
+	q.RequireConsistent = mode == ConsistencyConsistent
+}
+
+// writeOptions 根据单次写操作选项构造WriteOptions
+func (c *Client) writeOptions(opts ...WriteOption) *api.WriteOptions {
+	w := &api.WriteOptions{}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
@@ -0,0 +1,177 @@
+package consul
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// minVisibilityTimeout Consul会话TTL的最小值
+const minVisibilityTimeout = 10 * time.Second
+
+// Queue 基于KV前缀的轻量分布式队列，用于实例间分发任务而无需引入消息中间件。
+// 出队时以带TTL的会话锁定任务，确认前其他消费者不可见；消费者崩溃或未在可见性超时内确认时，
+// 会话失效释放锁，任务重新可见。Consul可能在TTL的两倍时间内才使会话失效
+type Queue struct {
+	client     *Client
+	prefix     string
+	visibility time.Duration
+}
+
+// Job 出队的任务，处理完成后需调用Ack，处理失败调用Nack立即放回队列
+type Job struct {
+	ID      string // 任务ID，按入队时间排序
+	Payload []byte // 任务内容
+
+	queue   *Queue
+	key     string
+	session string
+}
+
+// NewQueue 创建队列，任务存储在prefix下，visibility为出队后的可见性超时，最小10秒
+func (c *Client) NewQueue(prefix string, visibility time.Duration) *Queue {
+	if visibility < minVisibilityTimeout {
+		visibility = minVisibilityTimeout
+	}
+	return &Queue{
+		client:     c,
+		prefix:     strings.TrimSuffix(prefix, "/") + "/",
+		visibility: visibility,
+	}
+}
+
+// Enqueue 将任务加入队列，返回任务ID
+func (q *Queue) Enqueue(payload []byte) (string, error) {
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	id := fmt.Sprintf("%019d-%s", time.Now().UnixNano(), hex.EncodeToString(suffix))
+
+	key := q.prefix + id
+	ok, err := q.client.CAS(key, payload, 0)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", fmt.Errorf("job %s already exists", id)
+	}
+	return id, nil
+}
+
+// Dequeue 取出最早的可见任务，队列为空或全部任务都在处理中时返回nil
+func (q *Queue) Dequeue() (*Job, error) {
+	pairs, _, err := q.client.client.KV().List(q.prefix, q.client.kvQueryOptions(q.prefix))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list queue %s: %v", q.prefix, err)
+	}
+
+	var session string
+	for _, pair := range pairs {
+		if pair.Session != "" || pair.Key == q.prefix {
+			continue
+		}
+
+		if session == "" {
+			entry := &api.SessionEntry{
+				Name:      "queue:" + q.prefix,
+				TTL:       q.visibility.String(),
+				Behavior:  api.SessionBehaviorRelease,
+				LockDelay: time.Millisecond,
+			}
+			session, _, err = q.client.client.Session().Create(entry, q.client.writeOptions())
+			if err != nil {
+				return nil, fmt.Errorf("failed to create session: %v", err)
+			}
+		}
+
+		// 在事务中先校验索引再加锁：任务在List之后被其他消费者确认删除时，
+		// 直接加锁会以旧内容重新创建该键，导致任务被重复处理
+		ops := api.TxnOps{
+			{KV: &api.KVTxnOp{Verb: api.KVCheckIndex, Key: pair.Key, Index: pair.ModifyIndex}},
+			{KV: &api.KVTxnOp{Verb: api.KVLock, Key: pair.Key, Value: pair.Value, Flags: pair.Flags, Session: session}},
+		}
+		ok, _, _, err := q.client.client.Txn().Txn(ops, q.client.kvQueryOptions(pair.Key))
+		if err != nil {
+			q.destroySession(session)
+			return nil, fmt.Errorf("failed to acquire job %s: %v", pair.Key, err)
+		}
+		if ok {
+			return &Job{
+				ID:      strings.TrimPrefix(pair.Key, q.prefix),
+				Payload: pair.Value,
+				queue:   q,
+				key:     pair.Key,
+				session: session,
+			}, nil
+		}
+	}
+
+	if session != "" {
+		q.destroySession(session)
+	}
+	return nil, nil
+}
+
+// Len 返回队列中的任务数，包含处理中的任务
+func (q *Queue) Len() (int, error) {
+	keys, _, err := q.client.client.KV().Keys(q.prefix, "", q.client.kvQueryOptions(q.prefix))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list queue %s: %v", q.prefix, err)
+	}
+	return len(keys), nil
+}
+
+// Ack 确认任务处理完成并将其删除，可见性超时后任务已被其他消费者取走时返回错误
+func (j *Job) Ack() error {
+	defer j.queue.destroySession(j.session)
+
+	kv := j.queue.client.client.KV()
+	pair, _, err := kv.Get(j.key, j.queue.client.kvQueryOptions(j.key))
+	if err != nil {
+		return fmt.Errorf("failed to get job %s: %v", j.ID, err)
+	}
+	if pair == nil || pair.Session != j.session {
+		return fmt.Errorf("job %s is no longer held by this consumer", j.ID)
+	}
+	ok, _, err := kv.DeleteCAS(pair, j.queue.client.kvWriteOptions(j.key))
+	if err != nil {
+		return fmt.Errorf("failed to delete job %s: %v", j.ID, err)
+	}
+	if !ok {
+		return fmt.Errorf("job %s is no longer held by this consumer", j.ID)
+	}
+	return nil
+}
+
+// Nack 放弃处理，任务立即重新可见
+func (j *Job) Nack() error {
+	defer j.queue.destroySession(j.session)
+
+	pair := &api.KVPair{Key: j.key, Value: j.Payload, Session: j.session}
+	if _, _, err := j.queue.client.client.KV().Release(pair, j.queue.client.kvWriteOptions(j.key)); err != nil {
+		return fmt.Errorf("failed to release job %s: %v", j.ID, err)
+	}
+	return nil
+}
+
+// Extend 续期会话，延长任务的可见性超时，适用于耗时较长的任务
+func (j *Job) Extend() error {
+	entry, _, err := j.queue.client.client.Session().Renew(j.session, j.queue.client.writeOptions())
+	if err != nil {
+		return fmt.Errorf("failed to renew job %s: %v", j.ID, err)
+	}
+	if entry == nil {
+		return fmt.Errorf("job %s is no longer held by this consumer", j.ID)
+	}
+	return nil
+}
+
+// destroySession 销毁会话，失败时仅记录日志，会话会在TTL到期后自动失效
+func (q *Queue) destroySession(session string) {
+	if _, err := q.client.client.Session().Destroy(session, q.client.writeOptions()); err != nil {
+		q.client.logger.Printf("Failed to destroy queue session %s: %v", session, err)
+	}
+}
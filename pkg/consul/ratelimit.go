@@ -0,0 +1,86 @@
+package consul
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// errRateLimited 令牌不足时中止UpdateKey，不写入任何值
+var errRateLimited = errors.New("rate limited")
+
+// RateLimiter 基于KV的集群级令牌桶限流器，所有实例共享同一个桶，适用于按租户限制全局调用量。
+// 每次判定都是一次Get+CAS，适合QPS不高的全局配额；桶状态按本地时钟补充令牌，实例间时钟需大致同步
+type RateLimiter struct {
+	client *Client
+	prefix string
+	rate   float64 // 每秒补充的令牌数
+	burst  float64 // 桶容量
+}
+
+// bucketState 令牌桶在KV中的状态
+type bucketState struct {
+	Tokens  float64 `json:"tokens"`  // 剩余令牌数
+	Updated int64   `json:"updated"` // 上次更新时间（Unix纳秒）
+}
+
+// NewRateLimiter 创建令牌桶限流器，桶状态存储在prefix下，rate为每秒补充的令牌数，burst为桶容量
+func (c *Client) NewRateLimiter(prefix string, rate float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		client: c,
+		prefix: strings.TrimSuffix(prefix, "/") + "/",
+		rate:   rate,
+		burst:  float64(burst),
+	}
+}
+
+// Allow 判断key（如租户ID）是否允许一次请求，Consul不可用时放行并记录日志
+func (l *RateLimiter) Allow(key string) bool {
+	ok, err := l.AllowN(key, 1)
+	if err != nil {
+		l.client.logger.Printf("Rate limiter failed for %s, allowing request: %v", key, err)
+		return true
+	}
+	return ok
+}
+
+// AllowN 判断key是否允许消耗n个令牌，令牌不足时不消耗
+func (l *RateLimiter) AllowN(key string, n int) (bool, error) {
+	if key == "" {
+		return false, fmt.Errorf("key cannot be empty")
+	}
+
+	err := l.client.UpdateKey(l.prefix+key, func(old []byte) ([]byte, error) {
+		now := time.Now()
+		state := bucketState{Tokens: l.burst, Updated: now.UnixNano()}
+		if len(old) > 0 {
+			if err := json.Unmarshal(old, &state); err != nil {
+				return nil, fmt.Errorf("invalid bucket state for %s: %v", key, err)
+			}
+			elapsed := now.Sub(time.Unix(0, state.Updated)).Seconds()
+			if elapsed > 0 {
+				state.Tokens = math.Min(l.burst, state.Tokens+elapsed*l.rate)
+			}
+			state.Updated = now.UnixNano()
+		}
+
+		if state.Tokens < float64(n) {
+			return nil, errRateLimited
+		}
+		state.Tokens -= float64(n)
+		return json.Marshal(state)
+	})
+	if errors.Is(err, errRateLimited) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,216 @@
+package consul
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// ReconcilerStats 记录调和器的运行统计
+type ReconcilerStats struct {
+	Runs         int       // 调和执行次数
+	Registered   int       // 累计补注册的服务数
+	Deregistered int       // 累计注销的未知服务数
+	LastDrift    int       // 最近一次发现的偏差数量
+	LastRun      time.Time // 最近一次执行时间
+	LastError    error     // 最近一次执行的错误
+}
+
+// Reconciler 维护一组期望的服务注册，并定期将本地Agent的状态收敛到期望状态：
+// 补注册缺失或被修改的服务，注销ID匹配前缀但不在期望集合中的服务
+type Reconciler struct {
+	client   *Client
+	prefix   string
+	interval time.Duration
+
+	mu      sync.Mutex
+	desired map[string]*ServiceConfig
+	stats   ReconcilerStats
+	stopCh  chan struct{}
+	running bool
+}
+
+// NewReconciler 创建调和器，prefix为本调和器管理的服务ID前缀，为空时不会注销任何未知服务
+func (c *Client) NewReconciler(prefix string, interval time.Duration) *Reconciler {
+	if interval <= 0 {
+		interval = time.Second * 30
+	}
+	return &Reconciler{
+		client:   c,
+		prefix:   prefix,
+		interval: interval,
+		desired:  make(map[string]*ServiceConfig),
+	}
+}
+
+// Set 添加或更新一个期望的服务注册
+func (r *Reconciler) Set(cfg *ServiceConfig) error {
+	if cfg == nil {
+		return fmt.Errorf("service config cannot be nil")
+	}
+	if cfg.Name == "" {
+		return fmt.Errorf("service name cannot be empty")
+	}
+	if cfg.ID == "" {
+		cfg.ID = fmt.Sprintf("%s-%d", cfg.Name, cfg.Port)
+	}
+
+	r.mu.Lock()
+	r.desired[cfg.ID] = cfg
+	r.mu.Unlock()
+	return nil
+}
+
+// Remove 移除一个期望的服务注册，下次调和时会将其注销
+func (r *Reconciler) Remove(serviceID string) {
+	r.mu.Lock()
+	delete(r.desired, serviceID)
+	r.mu.Unlock()
+}
+
+// Reconcile 立即执行一次调和
+func (r *Reconciler) Reconcile() error {
+	r.mu.Lock()
+	desired := make(map[string]*ServiceConfig, len(r.desired))
+	for id, cfg := range r.desired {
+		desired[id] = cfg
+	}
+	r.mu.Unlock()
+
+	actual, err := r.client.client.Agent().Services()
+	if err != nil {
+		err = fmt.Errorf("failed to list agent services: %v", err)
+		r.record(0, 0, 0, err)
+		return err
+	}
+
+	var registered, deregistered, drift int
+	var lastErr error
+
+	// 补注册缺失或与期望不一致的服务
+	ids := make([]string, 0, len(desired))
+	for id := range desired {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		cfg := desired[id]
+		if svc, ok := actual[id]; ok && serviceMatches(svc, cfg) {
+			continue
+		}
+		drift++
+		if err := r.client.RegisterService(cfg); err != nil {
+			lastErr = err
+			continue
+		}
+		registered++
+	}
+
+	// 注销匹配前缀但不在期望集合中的服务
+	if r.prefix != "" {
+		for id := range actual {
+			if !strings.HasPrefix(id, r.prefix) {
+				continue
+			}
+			if _, ok := desired[id]; ok {
+				continue
+			}
+			drift++
+			if err := r.client.DeregisterService(id); err != nil {
+				lastErr = err
+				continue
+			}
+			deregistered++
+		}
+	}
+
+	if drift > 0 {
+		r.client.logger.Printf("Reconciler fixed drift: %d registered, %d deregistered", registered, deregistered)
+	}
+	r.record(drift, registered, deregistered, lastErr)
+	return lastErr
+}
+
+// Start 启动后台定期调和
+func (r *Reconciler) Start() {
+	r.mu.Lock()
+	if r.running {
+		r.mu.Unlock()
+		return
+	}
+	r.running = true
+	r.stopCh = make(chan struct{})
+	stopCh := r.stopCh
+	r.mu.Unlock()
+
+	r.client.goWorker("reconciler", func() {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		r.Reconcile()
+		for {
+			select {
+			case <-r.client.ctx.Done():
+				return
+			case <-stopCh:
+				return
+			case <-ticker.C:
+				if err := r.Reconcile(); err != nil {
+					r.client.logger.Printf("Reconcile failed: %v", err)
+				}
+			}
+		}
+	})
+}
+
+// Stop 停止后台调和
+func (r *Reconciler) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.running {
+		close(r.stopCh)
+		r.running = false
+	}
+}
+
+// Stats 返回调和器的运行统计
+func (r *Reconciler) Stats() ReconcilerStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stats
+}
+
+// record 更新运行统计
+func (r *Reconciler) record(drift, registered, deregistered int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stats.Runs++
+	r.stats.LastDrift = drift
+	r.stats.Registered += registered
+	r.stats.Deregistered += deregistered
+	r.stats.LastRun = time.Now()
+	r.stats.LastError = err
+}
+
+// serviceMatches 判断Agent上的服务是否与期望配置一致
+func serviceMatches(svc *api.AgentService, cfg *ServiceConfig) bool {
+	if svc.Service != cfg.Name || svc.Port != cfg.Port {
+		return false
+	}
+	if cfg.Address != "" && svc.Address != cfg.Address {
+		return false
+	}
+	if len(svc.Tags) != len(cfg.Tags) || !containsAll(svc.Tags, cfg.Tags) {
+		return false
+	}
+	for k, v := range cfg.Meta {
+		if svc.Meta[k] != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,358 @@
+package consul
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// RenderConfig 模板渲染器的配置
+type RenderConfig struct {
+	Template       string           // Go模板内容
+	Destination    string           // 输出文件路径，为空时只在内存中保留渲染结果
+	Perms          os.FileMode      // 输出文件权限，默认0644
+	Command        []string         // 渲染结果变化后执行的命令，例如 {"nginx", "-s", "reload"}
+	CommandTimeout time.Duration    // 命令执行超时，默认30秒
+	OnRender       func(string)     // 渲染结果变化后的回调
+	Debounce       time.Duration    // 数据变化后等待的静默时间，默认200毫秒
+	Funcs          template.FuncMap // 额外的模板函数
+}
+
+// Renderer 类似consul-template的嵌入式模板渲染器：模板中可通过以下函数引用Consul数据，
+// 被引用的KV和服务会自动监听，数据变化时重新渲染，结果变化时写入文件并执行命令。
+//
+//	key "path"              KV值，键不存在时为空字符串
+//	keyOrDefault "path" "v" KV值，键不存在时返回默认值
+//	ls "prefix"             前缀下的KV，key为去掉前缀后的相对路径
+//	service "name"          服务的健康实例列表（[]Instance）
+type Renderer struct {
+	client *Client
+	config RenderConfig
+	tmpl   *template.Template
+
+	mu       sync.Mutex
+	keys     map[string]*api.KVPair  // 已引用的KV键的最新值
+	prefixes map[string]api.KVPairs  // 已引用的KV前缀的最新值
+	services map[string][]Instance   // 已引用的服务的最新健康实例
+	handles  map[string]*WatchHandle // 依赖的监听句柄，key为依赖标识
+	output   string                  // 最近一次的渲染结果
+	applied  string                  // 最近一次成功写入文件并执行命令的渲染结果
+	rendered bool                    // 是否已成功应用过渲染结果
+
+	trigger chan struct{}
+	stopCh  chan struct{}
+	running bool
+}
+
+// NewRenderer 创建模板渲染器，模板解析失败时返回错误
+func (c *Client) NewRenderer(config RenderConfig) (*Renderer, error) {
+	if config.Perms == 0 {
+		config.Perms = 0o644
+	}
+	if config.CommandTimeout <= 0 {
+		config.CommandTimeout = 30 * time.Second
+	}
+	if config.Debounce <= 0 {
+		config.Debounce = 200 * time.Millisecond
+	}
+
+	r := &Renderer{
+		client:   c,
+		config:   config,
+		keys:     make(map[string]*api.KVPair),
+		prefixes: make(map[string]api.KVPairs),
+		services: make(map[string][]Instance),
+		handles:  make(map[string]*WatchHandle),
+		trigger:  make(chan struct{}, 1),
+	}
+
+	funcs := template.FuncMap{
+		"key":          r.key,
+		"keyOrDefault": r.keyOrDefault,
+		"ls":           r.ls,
+		"service":      r.service,
+	}
+	for name, fn := range config.Funcs {
+		funcs[name] = fn
+	}
+	tmpl, err := template.New("render").Funcs(funcs).Parse(config.Template)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %v", err)
+	}
+	r.tmpl = tmpl
+	return r, nil
+}
+
+// Render 立即渲染一次并返回结果，结果与上次成功应用的结果不同时写入文件、执行回调和命令，
+// 应用失败时下次渲染会重试
+func (r *Renderer) Render() (string, error) {
+	var buf bytes.Buffer
+	if err := r.tmpl.Execute(&buf, nil); err != nil {
+		return "", fmt.Errorf("failed to render template: %v", err)
+	}
+	output := buf.String()
+
+	r.mu.Lock()
+	changed := !r.rendered || output != r.applied
+	r.output = output
+	r.mu.Unlock()
+
+	if changed {
+		if err := r.apply(output); err != nil {
+			return output, err
+		}
+		r.mu.Lock()
+		r.applied = output
+		r.rendered = true
+		r.mu.Unlock()
+	}
+	return output, nil
+}
+
+// Output 返回最近一次的渲染结果
+func (r *Renderer) Output() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.output
+}
+
+// Start 渲染一次并开始监听模板引用的数据，数据变化时自动重新渲染
+func (r *Renderer) Start() error {
+	r.mu.Lock()
+	if r.running {
+		r.mu.Unlock()
+		return nil
+	}
+	r.running = true
+	r.stopCh = make(chan struct{})
+	stopCh := r.stopCh
+	r.mu.Unlock()
+
+	if _, err := r.Render(); err != nil {
+		r.Stop()
+		return err
+	}
+
+	r.client.goWorker("renderer "+r.config.Destination, func() {
+		var fire <-chan time.Time
+		for {
+			select {
+			case <-r.client.ctx.Done():
+				return
+			case <-stopCh:
+				return
+			case <-r.trigger:
+				fire = time.After(r.config.Debounce)
+			case <-fire:
+				fire = nil
+				if _, err := r.Render(); err != nil {
+					r.client.logger.Printf("Renderer failed: %v", err)
+				}
+			}
+		}
+	})
+	return nil
+}
+
+// Stop 停止监听，已渲染的文件保持不变
+func (r *Renderer) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.running {
+		return
+	}
+	close(r.stopCh)
+	r.running = false
+	for id, h := range r.handles {
+		h.Stop()
+		delete(r.handles, id)
+	}
+}
+
+// apply 将变化的渲染结果写入文件，并执行回调和命令
+func (r *Renderer) apply(output string) error {
+	if r.config.Destination != "" {
+		if err := writeFileAtomic(r.config.Destination, []byte(output), r.config.Perms); err != nil {
+			return fmt.Errorf("failed to write %s: %v", r.config.Destination, err)
+		}
+		r.client.logger.Printf("Template rendered to %s", r.config.Destination)
+	}
+	if r.config.OnRender != nil {
+		r.config.OnRender(output)
+	}
+	if len(r.config.Command) > 0 {
+		ctx, cancel := context.WithTimeout(r.client.ctx, r.config.CommandTimeout)
+		defer cancel()
+		out, err := exec.CommandContext(ctx, r.config.Command[0], r.config.Command[1:]...).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("failed to run command %q: %v: %s", strings.Join(r.config.Command, " "), err, out)
+		}
+	}
+	return nil
+}
+
+// notify 依赖数据变化时触发重新渲染
+func (r *Renderer) notify() {
+	select {
+	case r.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// watching 判断依赖是否已在监听中，未监听且渲染器已启动时由调用方启动监听
+func (r *Renderer) watching(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.handles[id]
+	return ok || !r.running
+}
+
+// track 记录依赖的监听句柄，渲染器已停止时直接停止监听
+func (r *Renderer) track(id string, h *WatchHandle, err error) {
+	if err != nil {
+		r.client.logger.Printf("Failed to watch %s: %v", id, err)
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.running || r.handles[id] != nil {
+		h.Stop()
+		return
+	}
+	r.handles[id] = h
+}
+
+// key 模板函数，返回KV值
+func (r *Renderer) key(key string) (string, error) {
+	r.mu.Lock()
+	pair, ok := r.keys[key]
+	r.mu.Unlock()
+	if !ok {
+		var err error
+		pair, _, err = r.client.client.KV().Get(key, r.client.kvQueryOptions(key))
+		if err != nil {
+			return "", fmt.Errorf("failed to get key %s: %v", key, err)
+		}
+		r.mu.Lock()
+		r.keys[key] = pair
+		r.mu.Unlock()
+	}
+
+	if id := "key:" + key; !r.watching(id) {
+		h, err := r.client.WatchKey(key, func(pair *api.KVPair) {
+			r.mu.Lock()
+			r.keys[key] = pair
+			r.mu.Unlock()
+			r.notify()
+		})
+		r.track(id, h, err)
+	}
+
+	if pair == nil {
+		return "", nil
+	}
+	return string(pair.Value), nil
+}
+
+// keyOrDefault 模板函数，键不存在时返回默认值
+func (r *Renderer) keyOrDefault(key, def string) (string, error) {
+	value, err := r.key(key)
+	if err != nil {
+		return "", err
+	}
+	r.mu.Lock()
+	pair := r.keys[key]
+	r.mu.Unlock()
+	if pair == nil {
+		return def, nil
+	}
+	return value, nil
+}
+
+// ls 模板函数，返回前缀下的KV，key为相对路径
+func (r *Renderer) ls(prefix string) (map[string]string, error) {
+	r.mu.Lock()
+	pairs, ok := r.prefixes[prefix]
+	r.mu.Unlock()
+	if !ok {
+		var err error
+		pairs, _, err = r.client.client.KV().List(prefix, r.client.kvQueryOptions(prefix))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list prefix %s: %v", prefix, err)
+		}
+		r.mu.Lock()
+		r.prefixes[prefix] = pairs
+		r.mu.Unlock()
+	}
+
+	if id := "ls:" + prefix; !r.watching(id) {
+		h, err := r.client.WatchKeyPrefix(prefix, func(pairs api.KVPairs) {
+			r.mu.Lock()
+			r.prefixes[prefix] = pairs
+			r.mu.Unlock()
+			r.notify()
+		})
+		r.track(id, h, err)
+	}
+
+	result := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		if rel := strings.TrimPrefix(pair.Key, prefix); rel != "" && !strings.HasSuffix(rel, "/") {
+			result[rel] = string(pair.Value)
+		}
+	}
+	return result, nil
+}
+
+// service 模板函数，返回服务的健康实例
+func (r *Renderer) service(name string) ([]Instance, error) {
+	r.mu.Lock()
+	instances, ok := r.services[name]
+	r.mu.Unlock()
+	if !ok {
+		entries, err := r.client.GetHealthyServices(name)
+		if err != nil {
+			return nil, err
+		}
+		instances = newInstances(entries)
+		r.mu.Lock()
+		r.services[name] = instances
+		r.mu.Unlock()
+	}
+
+	if id := "service:" + name; !r.watching(id) {
+		h, err := r.client.WatchService(name, true, func(entries []*api.ServiceEntry) {
+			r.mu.Lock()
+			r.services[name] = newInstances(entries)
+			r.mu.Unlock()
+			r.notify()
+		})
+		r.track(id, h, err)
+	}
+	return instances, nil
+}
+
+// writeFileAtomic 先写临时文件再重命名，避免读取方看到写了一半的文件
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package consul
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+)
+
+// ClusterReport 集群服务清单报告，供运维工具生成资产清单
+type ClusterReport struct {
+	GeneratedAt time.Time        `json:"generated_at"`         // 生成时间
+	Datacenter  string           `json:"datacenter,omitempty"` // 数据中心，未通过WithDatacenter指定时为空
+	Services    []*ServiceReport `json:"services"`             // 按名称排序的服务
+	Instances   int              `json:"instances"`            // 实例总数
+	Health      HealthSummary    `json:"health"`               // 所有实例的健康汇总
+}
+
+// ServiceReport 单个服务的统计信息
+type ServiceReport struct {
+	Name      string                    `json:"name"`      // 服务名称
+	Instances int                       `json:"instances"` // 实例数
+	Nodes     int                       `json:"nodes"`     // 实例分布的节点数
+	Health    HealthSummary             `json:"health"`    // 实例健康汇总
+	Tags      map[string]int            `json:"tags"`      // 标签到带该标签的实例数
+	Meta      map[string]map[string]int `json:"meta"`      // 元数据键到各取值的实例数
+}
+
+// HealthSummary 按聚合健康状态统计的实例数
+type HealthSummary struct {
+	Passing  int `json:"passing"`
+	Warning  int `json:"warning"`
+	Critical int `json:"critical"`
+}
+
+// ClusterReport 遍历目录中的所有服务，统计实例数、健康状态以及标签和元数据分布
+func (c *Client) ClusterReport() (*ClusterReport, error) {
+	services, err := c.GetAllServices()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ClusterReport{GeneratedAt: time.Now(), Datacenter: c.config.datacenter}
+	for _, name := range slices.Sorted(maps.Keys(services)) {
+		entries, _, err := c.client.Health().Service(name, "", false, c.queryOptions())
+		if err != nil {
+			return nil, fmt.Errorf("failed to get service %s: %v", name, err)
+		}
+
+		svc := &ServiceReport{
+			Name:      name,
+			Instances: len(entries),
+			Tags:      make(map[string]int),
+			Meta:      make(map[string]map[string]int),
+		}
+		nodes := make(map[string]bool)
+		for _, entry := range entries {
+			if entry.Node != nil {
+				nodes[entry.Node.Node] = true
+			}
+			svc.Health.add(entry.Checks.AggregatedStatus())
+			for _, tag := range entry.Service.Tags {
+				svc.Tags[tag]++
+			}
+			for k, v := range entry.Service.Meta {
+				if svc.Meta[k] == nil {
+					svc.Meta[k] = make(map[string]int)
+				}
+				svc.Meta[k][v]++
+			}
+		}
+		svc.Nodes = len(nodes)
+
+		report.Services = append(report.Services, svc)
+		report.Instances += svc.Instances
+		report.Health.Passing += svc.Health.Passing
+		report.Health.Warning += svc.Health.Warning
+		report.Health.Critical += svc.Health.Critical
+	}
+
+	return report, nil
+}
+
+// JSON 返回缩进格式的JSON报告
+func (r *ClusterReport) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// add 按聚合健康状态计数，维护模式视为critical
+func (h *HealthSummary) add(status string) {
+	switch status {
+	case "passing":
+		h.Passing++
+	case "warning":
+		h.Warning++
+	default:
+		h.Critical++
+	}
+}
@@ -0,0 +1,103 @@
+package consul
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy 对Consul API请求的统一重试策略，作用于KV、目录、健康检查和Agent等所有请求
+type RetryPolicy struct {
+	MaxRetries  int           // 最大重试次数，小于1时按2处理
+	Backoff     BackoffPolicy // 退避策略，Initial为0时使用100毫秒起、2倍增长、上限2秒、20%抖动
+	RetryWrites bool          // 是否重试PUT、DELETE等写请求，默认只重试GET、HEAD
+}
+
+// WithRetryPolicy 为发往Consul的请求启用重试，网络错误以及429、5xx响应按退避策略重试，
+// 响应带Retry-After时至少等待该时间，请求上下文结束时立即停止
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Config) {
+		c.retry = &policy
+	}
+}
+
+// retryTransport 按重试策略包装底层传输层
+type retryTransport struct {
+	base   http.RoundTripper
+	policy RetryPolicy
+}
+
+// newRetryTransport 创建重试传输层
+func newRetryTransport(base http.RoundTripper, policy RetryPolicy) http.RoundTripper {
+	if policy.MaxRetries < 1 {
+		policy.MaxRetries = 2
+	}
+	if policy.Backoff.Initial <= 0 {
+		policy.Backoff = BackoffPolicy{
+			Initial:    100 * time.Millisecond,
+			Max:        2 * time.Second,
+			Multiplier: 2,
+			Jitter:     0.2,
+		}
+	}
+	return &retryTransport{base: base, policy: policy}
+}
+
+// RoundTrip 实现http.RoundTripper
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	idempotent := req.Method == http.MethodGet || req.Method == http.MethodHead
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	if (!idempotent && !t.policy.RetryWrites) || !replayable {
+		return t.base.RoundTrip(req)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	for attempt := 0; attempt < t.policy.MaxRetries; attempt++ {
+		if err == nil && !retryableStatus(resp.StatusCode) {
+			break
+		}
+
+		delay := t.policy.Backoff.Delay(attempt)
+		if err == nil {
+			if after := retryAfter(resp); after > delay {
+				delay = after
+			}
+			resp.Body.Close()
+		}
+		if !sleepContext(req.Context(), delay) {
+			return nil, req.Context().Err()
+		}
+
+		next := req.Clone(req.Context())
+		if req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return nil, fmt.Errorf("failed to replay request body: %v", bodyErr)
+			}
+			next.Body = body
+		}
+		resp, err = t.base.RoundTrip(next)
+	}
+	return resp, err
+}
+
+// retryableStatus 判断响应状态码是否为可重试的临时错误，
+// 500通常是选主期间的"No cluster leader"或RPC转发失败
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter 解析响应的Retry-After秒数
+func retryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,656 @@
+package consul
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"math/big"
+	"net"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// ErrSchemaViolation 表示配置值不符合JSON Schema
+var ErrSchemaViolation = errors.New("config violates schema")
+
+// Schema 编译后的JSON Schema，支持常用的校验关键字：
+// type、enum、const、properties、patternProperties、required、additionalProperties、items、
+// minimum、maximum、exclusiveMinimum、exclusiveMaximum、multipleOf、
+// minLength、maxLength、pattern、format、minItems、maxItems、uniqueItems、
+// minProperties、maxProperties、allOf、anyOf、oneOf、not，
+// 以及指向文档内部的$ref（如"#/$defs/port"）；引用外部文档时编译失败，避免校验被静默跳过。
+// format支持date-time、date、time、email、hostname、ipv4、ipv6、uri、uuid和regex，其他格式不做校验
+type Schema struct {
+	always *bool   // 布尔schema，true接受任意值，false拒绝任意值
+	ref    *Schema // $ref指向的schema，与同级关键字同时生效
+
+	types []string
+	enum  []interface{}
+	cnst  *interface{}
+
+	properties           map[string]*Schema
+	patternProperties    []patternSchema
+	required             []string
+	additionalProperties *Schema
+	minProperties        *int
+	maxProperties        *int
+
+	items       *Schema
+	minItems    *int
+	maxItems    *int
+	uniqueItems bool
+
+	minimum          *float64
+	maximum          *float64
+	exclusiveMinimum *float64
+	exclusiveMaximum *float64
+	multipleOf       *float64
+
+	minLength *int
+	maxLength *int
+	pattern   *regexp.Regexp
+	format    string
+
+	allOf []*Schema
+	anyOf []*Schema
+	oneOf []*Schema
+	not   *Schema
+}
+
+// patternSchema patternProperties中的一项
+type patternSchema struct {
+	pattern *regexp.Regexp
+	schema  *Schema
+}
+
+// maxCompiledSchemas 编译缓存最多保留的schema数量，超过时淘汰最早加入的
+const maxCompiledSchemas = 128
+
+// schemaCache 按schema内容的摘要缓存编译结果，监听每次更新时无需重新编译
+type schemaCache struct {
+	mu      sync.Mutex
+	entries map[[sha256.Size]byte]*Schema
+	order   [][sha256.Size]byte
+}
+
+var compiledSchemas = &schemaCache{entries: make(map[[sha256.Size]byte]*Schema)}
+
+func (c *schemaCache) get(key [sha256.Size]byte) (*Schema, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.entries[key]
+	return s, ok
+}
+
+func (c *schemaCache) put(key [sha256.Size]byte, s *Schema) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; ok {
+		return
+	}
+	if len(c.order) >= maxCompiledSchemas {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+	c.entries[key] = s
+	c.order = append(c.order, key)
+}
+
+// CompileSchema 解析并编译JSON Schema
+func CompileSchema(data []byte) (*Schema, error) {
+	key := sha256.Sum256(data)
+	if s, ok := compiledSchemas.get(key); ok {
+		return s, nil
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %v", err)
+	}
+	sc := &schemaCompiler{root: doc, refs: make(map[string]*Schema)}
+	s, err := sc.compile(doc, "#")
+	if err != nil {
+		return nil, err
+	}
+	compiledSchemas.put(key, s)
+	return s, nil
+}
+
+// schemaCompiler 编译单个schema文档，记录已解析的$ref以支持递归引用
+type schemaCompiler struct {
+	root interface{}
+	refs map[string]*Schema
+}
+
+// resolveRef 编译$ref指向的节点，同一引用只编译一次，递归引用指向同一个Schema
+func (sc *schemaCompiler) resolveRef(ref, path string) (*Schema, error) {
+	if s, ok := sc.refs[ref]; ok {
+		return s, nil
+	}
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("invalid schema at %s/$ref: only references within the document are supported, got %q", path, ref)
+	}
+	node, err := resolvePointer(sc.root, strings.TrimPrefix(ref, "#"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema at %s/$ref: %v", path, err)
+	}
+
+	s := &Schema{}
+	sc.refs[ref] = s
+	compiled, err := sc.compile(node, ref)
+	if err != nil {
+		return nil, err
+	}
+	*s = *compiled
+	return s, nil
+}
+
+// resolvePointer 按RFC 6901在文档中查找JSON Pointer指向的节点
+func resolvePointer(doc interface{}, pointer string) (interface{}, error) {
+	if pointer == "" {
+		return doc, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", pointer)
+	}
+	node := doc
+	for _, token := range strings.Split(pointer[1:], "/") {
+		if unescaped, err := url.PathUnescape(token); err == nil {
+			token = unescaped
+		}
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		switch n := node.(type) {
+		case map[string]interface{}:
+			v, ok := n[token]
+			if !ok {
+				return nil, fmt.Errorf("reference %q not found", pointer)
+			}
+			node = v
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(n) {
+				return nil, fmt.Errorf("reference %q not found", pointer)
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("reference %q not found", pointer)
+		}
+	}
+	return node, nil
+}
+
+// compile 递归编译schema节点，path用于错误信息
+func (sc *schemaCompiler) compile(doc interface{}, path string) (*Schema, error) {
+	if b, ok := doc.(bool); ok {
+		return &Schema{always: &b}, nil
+	}
+	m, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid schema at %s: must be an object or boolean", path)
+	}
+
+	s := &Schema{}
+	var err error
+	if v, ok := m["$ref"]; ok {
+		ref, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid schema at %s/$ref: must be a string", path)
+		}
+		if s.ref, err = sc.resolveRef(ref, path); err != nil {
+			return nil, err
+		}
+	}
+	switch t := m["type"].(type) {
+	case nil:
+	case string:
+		s.types = []string{t}
+	case []interface{}:
+		for _, v := range t {
+			name, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid schema at %s/type: must be a string or array of strings", path)
+			}
+			s.types = append(s.types, name)
+		}
+	default:
+		return nil, fmt.Errorf("invalid schema at %s/type: must be a string or array of strings", path)
+	}
+	if v, ok := m["enum"]; ok {
+		if s.enum, ok = v.([]interface{}); !ok {
+			return nil, fmt.Errorf("invalid schema at %s/enum: must be an array", path)
+		}
+	}
+	if v, ok := m["const"]; ok {
+		s.cnst = &v
+	}
+
+	if v, ok := m["properties"]; ok {
+		props, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid schema at %s/properties: must be an object", path)
+		}
+		s.properties = make(map[string]*Schema, len(props))
+		for name, prop := range props {
+			if s.properties[name], err = sc.compile(prop, path+"/properties/"+name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if v, ok := m["patternProperties"]; ok {
+		props, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid schema at %s/patternProperties: must be an object", path)
+		}
+		for _, expr := range slices.Sorted(maps.Keys(props)) {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid schema at %s/patternProperties: %v", path, err)
+			}
+			sub, err := sc.compile(props[expr], path+"/patternProperties/"+expr)
+			if err != nil {
+				return nil, err
+			}
+			s.patternProperties = append(s.patternProperties, patternSchema{pattern: re, schema: sub})
+		}
+	}
+	if v, ok := m["required"]; ok {
+		list, ok := v.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid schema at %s/required: must be an array of strings", path)
+		}
+		for _, item := range list {
+			name, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid schema at %s/required: must be an array of strings", path)
+			}
+			s.required = append(s.required, name)
+		}
+	}
+	if v, ok := m["additionalProperties"]; ok {
+		if s.additionalProperties, err = sc.compile(v, path+"/additionalProperties"); err != nil {
+			return nil, err
+		}
+	}
+	if v, ok := m["items"]; ok {
+		if s.items, err = sc.compile(v, path+"/items"); err != nil {
+			return nil, err
+		}
+	}
+	if v, ok := m["not"]; ok {
+		if s.not, err = sc.compile(v, path+"/not"); err != nil {
+			return nil, err
+		}
+	}
+	for _, kw := range []struct {
+		name string
+		dst  *[]*Schema
+	}{{"allOf", &s.allOf}, {"anyOf", &s.anyOf}, {"oneOf", &s.oneOf}} {
+		v, ok := m[kw.name]
+		if !ok {
+			continue
+		}
+		list, ok := v.([]interface{})
+		if !ok || len(list) == 0 {
+			return nil, fmt.Errorf("invalid schema at %s/%s: must be a non-empty array", path, kw.name)
+		}
+		for i, item := range list {
+			sub, err := sc.compile(item, fmt.Sprintf("%s/%s/%d", path, kw.name, i))
+			if err != nil {
+				return nil, err
+			}
+			*kw.dst = append(*kw.dst, sub)
+		}
+	}
+
+	for _, kw := range []struct {
+		name string
+		dst  **float64
+	}{
+		{"minimum", &s.minimum}, {"maximum", &s.maximum},
+		{"exclusiveMinimum", &s.exclusiveMinimum}, {"exclusiveMaximum", &s.exclusiveMaximum},
+		{"multipleOf", &s.multipleOf},
+	} {
+		if v, ok := m[kw.name]; ok {
+			n, ok := v.(float64)
+			if !ok {
+				return nil, fmt.Errorf("invalid schema at %s/%s: must be a number", path, kw.name)
+			}
+			*kw.dst = &n
+		}
+	}
+	if s.multipleOf != nil && *s.multipleOf <= 0 {
+		return nil, fmt.Errorf("invalid schema at %s/multipleOf: must be greater than 0", path)
+	}
+	for _, kw := range []struct {
+		name string
+		dst  **int
+	}{
+		{"minLength", &s.minLength}, {"maxLength", &s.maxLength},
+		{"minItems", &s.minItems}, {"maxItems", &s.maxItems},
+		{"minProperties", &s.minProperties}, {"maxProperties", &s.maxProperties},
+	} {
+		if v, ok := m[kw.name]; ok {
+			n, ok := v.(float64)
+			if !ok || n < 0 || n != math.Trunc(n) {
+				return nil, fmt.Errorf("invalid schema at %s/%s: must be a non-negative integer", path, kw.name)
+			}
+			i := int(n)
+			*kw.dst = &i
+		}
+	}
+	if v, ok := m["pattern"]; ok {
+		expr, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid schema at %s/pattern: must be a string", path)
+		}
+		if s.pattern, err = regexp.Compile(expr); err != nil {
+			return nil, fmt.Errorf("invalid schema at %s/pattern: %v", path, err)
+		}
+	}
+	if v, ok := m["format"]; ok {
+		if s.format, ok = v.(string); !ok {
+			return nil, fmt.Errorf("invalid schema at %s/format: must be a string", path)
+		}
+	}
+	if v, ok := m["uniqueItems"].(bool); ok {
+		s.uniqueItems = v
+	}
+	return s, nil
+}
+
+// Validate 校验JSON文档，不符合时返回包装ErrSchemaViolation的错误，列出所有违反的位置
+func (s *Schema) Validate(data []byte) error {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("%w: invalid JSON: %v", ErrSchemaViolation, err)
+	}
+	var violations []string
+	s.validate(doc, "", &violations)
+	if len(violations) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrSchemaViolation, strings.Join(violations, "; "))
+}
+
+// validate 递归校验值，违反项以"JSON Pointer: 原因"的形式追加到violations
+func (s *Schema) validate(v interface{}, path string, violations *[]string) {
+	fail := func(format string, args ...interface{}) {
+		at := path
+		if at == "" {
+			at = "/"
+		}
+		*violations = append(*violations, at+": "+fmt.Sprintf(format, args...))
+	}
+
+	if s.always != nil {
+		if !*s.always {
+			fail("no value is allowed")
+		}
+		return
+	}
+
+	if len(s.types) > 0 && !slices.ContainsFunc(s.types, func(t string) bool { return matchesType(v, t) }) {
+		fail("expected %s, got %s", strings.Join(s.types, " or "), jsonType(v))
+		return
+	}
+	if s.enum != nil && !slices.ContainsFunc(s.enum, func(e interface{}) bool { return reflect.DeepEqual(e, v) }) {
+		fail("value is not one of the allowed values")
+	}
+	if s.cnst != nil && !reflect.DeepEqual(*s.cnst, v) {
+		fail("value must be %v", *s.cnst)
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for _, name := range s.required {
+			if _, ok := val[name]; !ok {
+				fail("missing required property %q", name)
+			}
+		}
+		if s.minProperties != nil && len(val) < *s.minProperties {
+			fail("must have at least %d properties", *s.minProperties)
+		}
+		if s.maxProperties != nil && len(val) > *s.maxProperties {
+			fail("must have at most %d properties", *s.maxProperties)
+		}
+		names := make([]string, 0, len(val))
+		for name := range val {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			child := path + "/" + escapePointer(name)
+			prop, known := s.properties[name]
+			if known {
+				prop.validate(val[name], child, violations)
+			}
+			for _, pp := range s.patternProperties {
+				if pp.pattern.MatchString(name) {
+					known = true
+					pp.schema.validate(val[name], child, violations)
+				}
+			}
+			if !known && s.additionalProperties != nil {
+				if s.additionalProperties.always != nil && !*s.additionalProperties.always {
+					fail("unknown property %q", name)
+					continue
+				}
+				s.additionalProperties.validate(val[name], child, violations)
+			}
+		}
+	case []interface{}:
+		if s.minItems != nil && len(val) < *s.minItems {
+			fail("must have at least %d items", *s.minItems)
+		}
+		if s.maxItems != nil && len(val) > *s.maxItems {
+			fail("must have at most %d items", *s.maxItems)
+		}
+		if s.uniqueItems {
+			for i := 1; i < len(val); i++ {
+				if slices.ContainsFunc(val[:i], func(e interface{}) bool { return reflect.DeepEqual(e, val[i]) }) {
+					fail("items must be unique")
+					break
+				}
+			}
+		}
+		if s.items != nil {
+			for i, item := range val {
+				s.items.validate(item, fmt.Sprintf("%s/%d", path, i), violations)
+			}
+		}
+	case float64:
+		if s.minimum != nil && val < *s.minimum {
+			fail("must be >= %v", *s.minimum)
+		}
+		if s.maximum != nil && val > *s.maximum {
+			fail("must be <= %v", *s.maximum)
+		}
+		if s.exclusiveMinimum != nil && val <= *s.exclusiveMinimum {
+			fail("must be > %v", *s.exclusiveMinimum)
+		}
+		if s.exclusiveMaximum != nil && val >= *s.exclusiveMaximum {
+			fail("must be < %v", *s.exclusiveMaximum)
+		}
+		if s.multipleOf != nil && !isMultipleOf(val, *s.multipleOf) {
+			fail("must be a multiple of %v", *s.multipleOf)
+		}
+	case string:
+		n := utf8.RuneCountInString(val)
+		if s.minLength != nil && n < *s.minLength {
+			fail("length must be >= %d", *s.minLength)
+		}
+		if s.maxLength != nil && n > *s.maxLength {
+			fail("length must be <= %d", *s.maxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(val) {
+			fail("must match pattern %q", s.pattern.String())
+		}
+		if s.format != "" && !matchesFormat(val, s.format) {
+			fail("must be a valid %s", s.format)
+		}
+	}
+
+	if s.ref != nil {
+		s.ref.validate(v, path, violations)
+	}
+	for _, sub := range s.allOf {
+		sub.validate(v, path, violations)
+	}
+	if len(s.anyOf) > 0 && !slices.ContainsFunc(s.anyOf, func(sub *Schema) bool { return sub.matches(v) }) {
+		fail("must match at least one schema in anyOf")
+	}
+	if len(s.oneOf) > 0 {
+		matched := 0
+		for _, sub := range s.oneOf {
+			if sub.matches(v) {
+				matched++
+			}
+		}
+		if matched != 1 {
+			fail("must match exactly one schema in oneOf, matched %d", matched)
+		}
+	}
+	if s.not != nil && s.not.matches(v) {
+		fail("must not match schema in not")
+	}
+}
+
+// matches 判断值是否符合schema，不收集违反项
+func (s *Schema) matches(v interface{}) bool {
+	var violations []string
+	s.validate(v, "", &violations)
+	return len(violations) == 0
+}
+
+// isMultipleOf 按十进制精确判断val是否为divisor的整数倍，避免0.3除以0.1等浮点误差导致误判
+func isMultipleOf(val, divisor float64) bool {
+	v, ok1 := new(big.Rat).SetString(strconv.FormatFloat(val, 'g', -1, 64))
+	d, ok2 := new(big.Rat).SetString(strconv.FormatFloat(divisor, 'g', -1, 64))
+	if !ok1 || !ok2 {
+		q := val / divisor
+		return q == math.Trunc(q)
+	}
+	return new(big.Rat).Quo(v, d).IsInt()
+}
+
+// uuidPattern 匹配RFC 4122格式的UUID
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// hostnamePattern 匹配RFC 1123主机名中的单个标签
+var hostnamePattern = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`)
+
+// matchesFormat 判断字符串是否符合format，不支持的格式视为通过
+func matchesFormat(v, format string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, v)
+		return err == nil
+	case "date":
+		_, err := time.Parse(time.DateOnly, v)
+		return err == nil
+	case "time":
+		_, err := time.Parse("15:04:05Z07:00", v)
+		return err == nil
+	case "email":
+		addr, err := mail.ParseAddress(v)
+		return err == nil && addr.Address == v
+	case "hostname":
+		if v == "" || len(v) > 253 {
+			return false
+		}
+		for _, label := range strings.Split(strings.TrimSuffix(v, "."), ".") {
+			if !hostnamePattern.MatchString(label) {
+				return false
+			}
+		}
+		return true
+	case "ipv4":
+		ip := net.ParseIP(v)
+		return ip != nil && ip.To4() != nil && !strings.Contains(v, ":")
+	case "ipv6":
+		return net.ParseIP(v) != nil && strings.Contains(v, ":")
+	case "uri":
+		u, err := url.Parse(v)
+		return err == nil && u.Scheme != ""
+	case "uuid":
+		return uuidPattern.MatchString(v)
+	case "regex":
+		_, err := regexp.Compile(v)
+		return err == nil
+	}
+	return true
+}
+
+// matchesType 判断值是否属于JSON Schema类型
+func matchesType(v interface{}, t string) bool {
+	if t == "integer" {
+		n, ok := v.(float64)
+		return ok && n == math.Trunc(n)
+	}
+	return jsonType(v) == t
+}
+
+// jsonType 返回值的JSON Schema类型名
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// escapePointer 按RFC 6901转义JSON Pointer中的属性名
+func escapePointer(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
+
+type schemaKey struct{}
+
+// WithJSONSchema 写入前按JSON Schema校验值，不符合时拒绝写入并返回ErrSchemaViolation，
+// 作用于Put、CAS、UpdateKey、PatchConfig和PutConfigChecked
+func WithJSONSchema(schema []byte) WriteOption {
+	return func(w *api.WriteOptions) {
+		*w = *w.WithContext(context.WithValue(w.Context(), schemaKey{}, schema))
+	}
+}
+
+// validateWrite 按WithJSONSchema设置的schema校验待写入的值
+func validateWrite(key string, value []byte, w *api.WriteOptions) error {
+	schema, ok := w.Context().Value(schemaKey{}).([]byte)
+	if !ok {
+		return nil
+	}
+	s, err := CompileSchema(schema)
+	if err != nil {
+		return err
+	}
+	if err := s.Validate(value); err != nil {
+		return fmt.Errorf("refusing to write %s: %w", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package consul_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stones-hub/taurus-pro-consul/pkg/consul"
+)
+
+// schemaCase 一条校验用例，按JSON Schema官方测试集的形式组织：同一schema下的多个实例及期望结果
+type schemaCase struct {
+	name     string
+	schema   string
+	instance string
+	valid    bool
+}
+
+func TestSchemaKeywords(t *testing.T) {
+	cases := []schemaCase{
+		{"boolean true", `true`, `{"a":1}`, true},
+		{"boolean false", `false`, `1`, false},
+
+		{"type match", `{"type":"string"}`, `"x"`, true},
+		{"type mismatch", `{"type":"string"}`, `1`, false},
+		{"type list", `{"type":["string","null"]}`, `null`, true},
+		{"integer accepts 1.0", `{"type":"integer"}`, `1.0`, true},
+		{"integer rejects 1.5", `{"type":"integer"}`, `1.5`, false},
+
+		{"enum match", `{"enum":["a",1,null]}`, `1`, true},
+		{"enum mismatch", `{"enum":["a",1,null]}`, `"b"`, false},
+		{"const match", `{"const":{"a":[1]}}`, `{"a":[1]}`, true},
+		{"const mismatch", `{"const":{"a":[1]}}`, `{"a":[2]}`, false},
+
+		{"properties valid", `{"properties":{"port":{"type":"integer"}}}`, `{"port":80}`, true},
+		{"properties invalid", `{"properties":{"port":{"type":"integer"}}}`, `{"port":"80"}`, false},
+		{"required present", `{"required":["port"]}`, `{"port":80}`, true},
+		{"required missing", `{"required":["port"]}`, `{}`, false},
+		{"additionalProperties false", `{"properties":{"a":{}},"additionalProperties":false}`, `{"a":1,"b":2}`, false},
+		{"additionalProperties schema", `{"additionalProperties":{"type":"integer"}}`, `{"a":1,"b":"x"}`, false},
+		{"minProperties", `{"minProperties":2}`, `{"a":1}`, false},
+		{"maxProperties", `{"maxProperties":1}`, `{"a":1,"b":2}`, false},
+
+		{"patternProperties valid", `{"patternProperties":{"^x-":{"type":"string"}}}`, `{"x-a":"v","y":1}`, true},
+		{"patternProperties invalid", `{"patternProperties":{"^x-":{"type":"string"}}}`, `{"x-a":1}`, false},
+		{"patternProperties not additional", `{"patternProperties":{"^x-":{}},"additionalProperties":false}`, `{"x-a":1}`, true},
+		{"patternProperties with additional", `{"patternProperties":{"^x-":{}},"additionalProperties":false}`, `{"y":1}`, false},
+
+		{"items valid", `{"items":{"type":"integer"}}`, `[1,2]`, true},
+		{"items invalid", `{"items":{"type":"integer"}}`, `[1,"2"]`, false},
+		{"minItems", `{"minItems":2}`, `[1]`, false},
+		{"maxItems", `{"maxItems":1}`, `[1,2]`, false},
+		{"uniqueItems valid", `{"uniqueItems":true}`, `[1,{"a":1},{"a":2}]`, true},
+		{"uniqueItems invalid", `{"uniqueItems":true}`, `[{"a":1},{"a":1}]`, false},
+
+		{"minimum", `{"minimum":1}`, `0`, false},
+		{"minimum boundary", `{"minimum":1}`, `1`, true},
+		{"maximum", `{"maximum":1}`, `2`, false},
+		{"exclusiveMinimum", `{"exclusiveMinimum":1}`, `1`, false},
+		{"exclusiveMaximum", `{"exclusiveMaximum":1}`, `1`, false},
+		{"multipleOf integer", `{"multipleOf":2}`, `4`, true},
+		{"multipleOf integer invalid", `{"multipleOf":2}`, `5`, false},
+		{"multipleOf decimal", `{"multipleOf":0.1}`, `0.3`, true},
+		{"multipleOf decimal 0.01", `{"multipleOf":0.01}`, `19.99`, true},
+		{"multipleOf decimal invalid", `{"multipleOf":0.1}`, `0.35`, false},
+
+		{"minLength counts runes", `{"minLength":2}`, `"中文"`, true},
+		{"minLength", `{"minLength":2}`, `"a"`, false},
+		{"maxLength", `{"maxLength":1}`, `"ab"`, false},
+		{"pattern match", `{"pattern":"^v[0-9]+$"}`, `"v2"`, true},
+		{"pattern mismatch", `{"pattern":"^v[0-9]+$"}`, `"x2"`, false},
+
+		{"format date-time", `{"format":"date-time"}`, `"2024-01-02T15:04:05Z"`, true},
+		{"format date-time invalid", `{"format":"date-time"}`, `"2024-01-02"`, false},
+		{"format date", `{"format":"date"}`, `"2024-02-30"`, false},
+		{"format time", `{"format":"time"}`, `"15:04:05+08:00"`, true},
+		{"format email", `{"format":"email"}`, `"ops@example.com"`, true},
+		{"format email invalid", `{"format":"email"}`, `"Ops <ops@example.com>"`, false},
+		{"format hostname", `{"format":"hostname"}`, `"api.example.com"`, true},
+		{"format hostname invalid", `{"format":"hostname"}`, `"-bad.example.com"`, false},
+		{"format ipv4", `{"format":"ipv4"}`, `"10.0.0.1"`, true},
+		{"format ipv4 invalid", `{"format":"ipv4"}`, `"::1"`, false},
+		{"format ipv6", `{"format":"ipv6"}`, `"2001:db8::1"`, true},
+		{"format ipv6 invalid", `{"format":"ipv6"}`, `"10.0.0.1"`, false},
+		{"format uri", `{"format":"uri"}`, `"https://example.com/a"`, true},
+		{"format uri invalid", `{"format":"uri"}`, `"/relative"`, false},
+		{"format uuid", `{"format":"uuid"}`, `"123e4567-e89b-12d3-a456-426614174000"`, true},
+		{"format uuid invalid", `{"format":"uuid"}`, `"123e4567"`, false},
+		{"format regex invalid", `{"format":"regex"}`, `"(["`, false},
+		{"format unknown ignored", `{"format":"x-custom"}`, `"anything"`, true},
+		{"format ignores non-strings", `{"format":"ipv4"}`, `1`, true},
+
+		{"allOf", `{"allOf":[{"minimum":1},{"maximum":3}]}`, `4`, false},
+		{"anyOf valid", `{"anyOf":[{"type":"string"},{"minimum":5}]}`, `6`, true},
+		{"anyOf invalid", `{"anyOf":[{"type":"string"},{"minimum":5}]}`, `1`, false},
+		{"oneOf valid", `{"oneOf":[{"type":"integer"},{"minimum":5}]}`, `1`, true},
+		{"oneOf both match", `{"oneOf":[{"type":"integer"},{"minimum":5}]}`, `6`, false},
+		{"not", `{"not":{"type":"null"}}`, `null`, false},
+
+		{"$ref defs", `{"$defs":{"port":{"type":"integer","maximum":65535}},"properties":{"port":{"$ref":"#/$defs/port"}}}`, `{"port":70000}`, false},
+		{"$ref definitions", `{"definitions":{"port":{"type":"integer"}},"items":{"$ref":"#/definitions/port"}}`, `[1,2]`, true},
+		{"$ref escaped pointer", `{"$defs":{"a/b":{"type":"string"}},"$ref":"#/$defs/a~1b"}`, `1`, false},
+		{"$ref recursive", `{"type":"object","properties":{"children":{"type":"array","items":{"$ref":"#"}}},"required":["name"]}`, `{"name":"a","children":[{"name":"b","children":[{}]}]}`, false},
+		{"$ref with siblings", `{"$defs":{"n":{"type":"integer"}},"$ref":"#/$defs/n","minimum":2}`, `1`, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := consul.CompileSchema([]byte(tc.schema))
+			if err != nil {
+				t.Fatalf("CompileSchema: %v", err)
+			}
+			err = s.Validate([]byte(tc.instance))
+			if tc.valid && err != nil {
+				t.Fatalf("expected valid, got %v", err)
+			}
+			if !tc.valid {
+				if err == nil {
+					t.Fatal("expected violation, got nil")
+				}
+				if !errors.Is(err, consul.ErrSchemaViolation) {
+					t.Fatalf("error %v does not wrap ErrSchemaViolation", err)
+				}
+			}
+		})
+	}
+}
+
+func TestCompileSchemaRejectsInvalid(t *testing.T) {
+	for _, schema := range []string{
+		`"string"`,
+		`{"type":1}`,
+		`{"multipleOf":0}`,
+		`{"minLength":-1}`,
+		`{"pattern":"("}`,
+		`{"patternProperties":{"(":{}}}`,
+		`{"$ref":"other.json#/a"}`,
+		`{"$ref":"#/$defs/missing"}`,
+		`{"anyOf":[]}`,
+	} {
+		if _, err := consul.CompileSchema([]byte(schema)); err == nil {
+			t.Errorf("CompileSchema(%s) succeeded, want error", schema)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package consul
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// version 是语义化版本号
+type version struct {
+	major, minor, patch int
+	pre                 string
+}
+
+// parseVersion 解析语义化版本号，允许"v"前缀以及省略次版本号和修订号
+func parseVersion(s string) (version, error) {
+	var v version
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if s == "" {
+		return v, fmt.Errorf("empty version")
+	}
+
+	// 去掉构建元数据并拆出预发布版本
+	if idx := strings.IndexByte(s, '+'); idx >= 0 {
+		s = s[:idx]
+	}
+	if idx := strings.IndexByte(s, '-'); idx >= 0 {
+		v.pre = s[idx+1:]
+		s = s[:idx]
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return v, fmt.Errorf("invalid version: %s", s)
+	}
+	nums := []*int{&v.major, &v.minor, &v.patch}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("invalid version: %s", s)
+		}
+		*nums[i] = n
+	}
+	return v, nil
+}
+
+// compare 比较两个版本号，返回-1、0或1；带预发布标识的版本低于对应的正式版本
+func (v version) compare(o version) int {
+	for _, d := range [][2]int{{v.major, o.major}, {v.minor, o.minor}, {v.patch, o.patch}} {
+		if d[0] != d[1] {
+			if d[0] < d[1] {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case v.pre == o.pre:
+		return 0
+	case v.pre == "":
+		return 1
+	case o.pre == "":
+		return -1
+	case v.pre < o.pre:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// versionConstraint 是版本约束，外层为"||"分隔的或关系，内层为空格或逗号分隔的与关系
+type versionConstraint [][]func(version) bool
+
+// parseConstraint 解析版本约束，支持：
+// 比较运算（>=1.2.0 <2.0.0、!=1.3.0）、通配符（1.2.x、1.*、*）、^1.2.3、~1.2.3 以及"||"组合
+func parseConstraint(s string) (versionConstraint, error) {
+	var c versionConstraint
+	for _, group := range strings.Split(s, "||") {
+		fields := strings.FieldsFunc(group, func(r rune) bool { return r == ' ' || r == ',' })
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("invalid version constraint: %q", s)
+		}
+
+		var terms []func(version) bool
+		for _, f := range fields {
+			term, err := parseConstraintTerm(f)
+			if err != nil {
+				return nil, err
+			}
+			terms = append(terms, term...)
+		}
+		c = append(c, terms)
+	}
+	return c, nil
+}
+
+// check 判断版本是否满足约束
+func (c versionConstraint) check(v version) bool {
+	for _, group := range c {
+		ok := true
+		for _, term := range group {
+			if !term(v) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// parseConstraintTerm 解析单个约束项，返回与关系的判断函数列表
+func parseConstraintTerm(term string) ([]func(version) bool, error) {
+	for _, op := range []string{">=", "<=", "!=", ">", "<", "="} {
+		if !strings.HasPrefix(term, op) {
+			continue
+		}
+		v, err := parseVersion(term[len(op):])
+		if err != nil {
+			return nil, fmt.Errorf("invalid version constraint %q: %v", term, err)
+		}
+		return []func(version) bool{compareTerm(op, v)}, nil
+	}
+
+	switch term[0] {
+	case '^':
+		v, err := parseVersion(term[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid version constraint %q: %v", term, err)
+		}
+		upper := version{major: v.major + 1}
+		if v.major == 0 {
+			upper = version{minor: v.minor + 1}
+		}
+		return []func(version) bool{compareTerm(">=", v), compareTerm("<", upper)}, nil
+	case '~':
+		v, err := parseVersion(term[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid version constraint %q: %v", term, err)
+		}
+		upper := version{major: v.major, minor: v.minor + 1}
+		return []func(version) bool{compareTerm(">=", v), compareTerm("<", upper)}, nil
+	}
+
+	return wildcardTerm(term)
+}
+
+// wildcardTerm 解析通配符或不带运算符的版本，省略的部分视为通配符
+func wildcardTerm(term string) ([]func(version) bool, error) {
+	term = strings.TrimPrefix(term, "v")
+	if term == "*" || term == "x" || term == "X" {
+		return []func(version) bool{func(version) bool { return true }}, nil
+	}
+
+	parts := strings.Split(term, ".")
+	var fixed []int
+	for _, p := range parts {
+		if p == "x" || p == "X" || p == "*" {
+			break
+		}
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			// 不是通配符形式，按精确版本处理（可能带预发布标识）
+			v, err := parseVersion(term)
+			if err != nil {
+				return nil, fmt.Errorf("invalid version constraint %q: %v", term, err)
+			}
+			return []func(version) bool{compareTerm("=", v)}, nil
+		}
+		fixed = append(fixed, n)
+	}
+
+	switch len(fixed) {
+	case 0:
+		return []func(version) bool{func(version) bool { return true }}, nil
+	case 1:
+		lower := version{major: fixed[0]}
+		return []func(version) bool{compareTerm(">=", lower), compareTerm("<", version{major: fixed[0] + 1})}, nil
+	case 2:
+		lower := version{major: fixed[0], minor: fixed[1]}
+		upper := version{major: fixed[0], minor: fixed[1] + 1}
+		return []func(version) bool{compareTerm(">=", lower), compareTerm("<", upper)}, nil
+	default:
+		v := version{major: fixed[0], minor: fixed[1], patch: fixed[2]}
+		return []func(version) bool{compareTerm("=", v)}, nil
+	}
+}
+
+// compareTerm 根据比较运算符构造判断函数
+func compareTerm(op string, target version) func(version) bool {
+	return func(v version) bool {
+		cmp := v.compare(target)
+		switch op {
+		case ">=":
+			return cmp >= 0
+		case "<=":
+			return cmp <= 0
+		case ">":
+			return cmp > 0
+		case "<":
+			return cmp < 0
+		case "!=":
+			return cmp != 0
+		default:
+			return cmp == 0
+		}
+	}
+}
+
+// isVersionPattern 判断元数据过滤值是否为版本约束表达式
+func isVersionPattern(s string) bool {
+	if s == "" {
+		return false
+	}
+	if strings.ContainsAny(s, "<>=^~*|") {
+		return true
+	}
+	for _, p := range strings.Split(strings.TrimPrefix(s, "v"), ".") {
+		if p == "x" || p == "X" {
+			return true
+		}
+	}
+	return false
+}
@@ -2,6 +2,12 @@ package consul
 
 import (
 	"fmt"
+	"maps"
+	"net"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
 
 	"github.com/hashicorp/consul/api"
 )
@@ -15,10 +21,20 @@ type ServiceConfig struct {
 	Port    int               // 服务端口
 	Meta    map[string]string // 服务元数据
 	Checks  []*CheckConfig    // 健康检查配置
+	Token   string            // 注册和注销该服务使用的ACL Token，为空时使用客户端Token
+}
+
+// DependsOn 为服务添加别名检查，使其健康状态依赖于指定的服务实例，
+// 任一依赖不健康时该服务也被视为不健康
+func (cfg *ServiceConfig) DependsOn(serviceIDs ...string) *ServiceConfig {
+	for _, id := range serviceIDs {
+		cfg.Checks = append(cfg.Checks, AliasCheck(id))
+	}
+	return cfg
 }
 
 // RegisterService 注册服务到Consul
-func (c *Client) RegisterService(cfg *ServiceConfig) error {
+func (c *Client) RegisterService(cfg *ServiceConfig, opts ...WriteOption) error {
 	if cfg == nil {
 		return fmt.Errorf("service config cannot be nil")
 	}
@@ -37,6 +53,18 @@ func (c *Client) RegisterService(cfg *ServiceConfig) error {
 		cfg.ID = fmt.Sprintf("%s-%d", cfg.Name, cfg.Port)
 	}
 
+	// 如果没有指定地址，自动探测本机地址
+	if cfg.Address == "" {
+		address, err := DetectLocalAddress(c.config.addressDetect)
+		if err != nil {
+			return fmt.Errorf("failed to detect local address: %v", err)
+		}
+		cfg.Address = address
+	}
+
+	// 合并客户端级别的默认标签和元数据
+	c.applyDefaults(cfg)
+
 	// 创建服务注册配置
 	reg := &api.AgentServiceRegistration{
 		ID:      cfg.ID,
@@ -51,46 +79,217 @@ func (c *Client) RegisterService(cfg *ServiceConfig) error {
 	if len(cfg.Checks) > 0 {
 		reg.Checks = make([]*api.AgentServiceCheck, len(cfg.Checks))
 		for i, check := range cfg.Checks {
-			reg.Checks[i] = &api.AgentServiceCheck{
-				Name:                           fmt.Sprintf("service:%s check", cfg.ID),
-				HTTP:                           check.HTTP,
-				TCP:                            check.TCP,
-				Interval:                       check.Interval.String(),
-				Timeout:                        check.Timeout.String(),
-				DeregisterCriticalServiceAfter: check.DeregisterAfter.String(),
-				TLSSkipVerify:                  check.TLSSkipVerify,
-				Method:                         check.Method,
-				Header:                         check.Header,
-			}
+			reg.Checks[i] = check.agentCheck(cfg.ID)
 		}
 	}
 
 	// 注册服务
-	if err := c.client.Agent().ServiceRegister(reg); err != nil {
+	w := c.writeOptions(opts...)
+	if w.Token == "" {
+		w.Token = cfg.Token
+	}
+	regOpts := api.ServiceRegisterOpts{Token: w.Token}.WithContext(w.Context())
+	if err := c.client.Agent().ServiceRegisterOpts(reg, regOpts); err != nil {
+		c.audit(AuditEntry{Action: AuditRegister, ServiceID: cfg.ID, Service: cfg.Name}, err)
 		return fmt.Errorf("failed to register service: %v", err)
 	}
+	c.audit(AuditEntry{Action: AuditRegister, ServiceID: cfg.ID, Service: cfg.Name}, nil)
+	c.emit(Event{Type: EventServiceRegistered, Service: cfg.Name, ServiceID: cfg.ID})
+
+	c.mu.Lock()
+	c.services[cfg.ID] = cfg
+	c.mu.Unlock()
 
 	c.logger.Printf("Service registered successfully: %s (ID: %s)", cfg.Name, cfg.ID)
 	return nil
 }
 
+// applyDefaults 将客户端默认的标签和元数据合并到服务配置中，服务自身的元数据优先。
+// 合并前复制Tags和Meta，避免修改调用方在多个配置间共享的切片和map
+func (c *Client) applyDefaults(cfg *ServiceConfig) {
+	if len(c.config.defaultTags) > 0 {
+		cfg.Tags = slices.Clone(cfg.Tags)
+	}
+	for _, tag := range c.config.defaultTags {
+		if !containsAll(cfg.Tags, []string{tag}) {
+			cfg.Tags = append(cfg.Tags, tag)
+		}
+	}
+
+	if len(c.config.defaultMeta) > 0 {
+		cfg.Meta = maps.Clone(cfg.Meta)
+		if cfg.Meta == nil {
+			cfg.Meta = make(map[string]string, len(c.config.defaultMeta))
+		}
+	}
+	for k, v := range c.config.defaultMeta {
+		if _, ok := cfg.Meta[k]; !ok {
+			cfg.Meta[k] = v
+		}
+	}
+}
+
+// RegisterServiceWithListener 使用监听器实际绑定的端口注册服务，
+// 适用于监听":0"等动态端口的场景，健康检查地址中的端口会同步更新
+func (c *Client) RegisterServiceWithListener(cfg *ServiceConfig, ln net.Listener, opts ...WriteOption) error {
+	if cfg == nil {
+		return fmt.Errorf("service config cannot be nil")
+	}
+	if ln == nil {
+		return fmt.Errorf("listener cannot be nil")
+	}
+
+	tcpAddr, ok := ln.Addr().(*net.TCPAddr)
+	if !ok {
+		return fmt.Errorf("unsupported listener address: %s", ln.Addr())
+	}
+	cfg.Port = tcpAddr.Port
+
+	// 监听在具体地址上时直接使用该地址
+	if cfg.Address == "" && !tcpAddr.IP.IsUnspecified() && tcpAddr.IP != nil {
+		cfg.Address = tcpAddr.IP.String()
+	}
+
+	// 先确定服务地址，用于补全健康检查地址
+	if cfg.Address == "" {
+		address, err := DetectLocalAddress(c.config.addressDetect)
+		if err != nil {
+			return fmt.Errorf("failed to detect local address: %v", err)
+		}
+		cfg.Address = address
+	}
+
+	for _, check := range cfg.Checks {
+		if check == nil {
+			continue
+		}
+		check.HTTP = rewriteCheckURL(check.HTTP, cfg.Address, cfg.Port)
+		check.TCP = rewriteCheckAddr(check.TCP, cfg.Address, cfg.Port)
+	}
+
+	return c.RegisterService(cfg, opts...)
+}
+
+// rewriteCheckURL 将健康检查URL中缺失或为0的端口替换为实际端口，
+// 以"/"开头的路径会补全为完整的URL
+func rewriteCheckURL(raw, address string, port int) string {
+	if raw == "" {
+		return raw
+	}
+	if strings.HasPrefix(raw, "/") {
+		return baseURL(joinHostPort(address, port)) + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+	if p := u.Port(); p == "" || p == "0" {
+		host := u.Hostname()
+		if host == "" {
+			host = address
+		}
+		u.Host = joinHostPort(host, port)
+	}
+	return u.String()
+}
+
+// rewriteCheckAddr 将TCP健康检查地址中缺失或为0的端口替换为实际端口
+func rewriteCheckAddr(raw, address string, port int) string {
+	if raw == "" {
+		return raw
+	}
+
+	host, p, err := net.SplitHostPort(raw)
+	if err != nil {
+		return raw
+	}
+	if p != "0" {
+		return raw
+	}
+	if host == "" {
+		host = address
+	}
+	return joinHostPort(host, port)
+}
+
 // DeregisterService 注销服务
-func (c *Client) DeregisterService(serviceID string) error {
+func (c *Client) DeregisterService(serviceID string, opts ...QueryOption) error {
 	if serviceID == "" {
 		return fmt.Errorf("service ID cannot be empty")
 	}
 
-	if err := c.client.Agent().ServiceDeregister(serviceID); err != nil {
+	q := &api.QueryOptions{}
+	for _, opt := range opts {
+		opt(q)
+	}
+	if q.Token == "" {
+		q.Token = c.serviceToken(serviceID)
+	}
+	err := c.client.Agent().ServiceDeregisterOpts(serviceID, q)
+	c.audit(AuditEntry{Action: AuditDeregister, ServiceID: serviceID}, err)
+	if err != nil {
 		return fmt.Errorf("failed to deregister service: %v", err)
 	}
 
+	c.mu.Lock()
+	var name string
+	if cfg, ok := c.services[serviceID]; ok {
+		name = cfg.Name
+	}
+	delete(c.services, serviceID)
+	c.mu.Unlock()
+
+	c.emit(Event{Type: EventServiceDeregistered, Service: name, ServiceID: serviceID})
 	c.logger.Printf("Service deregistered successfully: %s", serviceID)
 	return nil
 }
 
+// DeregisterServiceByName 注销本地Agent上指定名称的所有服务实例，返回已注销的实例ID。
+// meta不为空时只注销元数据全部匹配的实例，可用于只清理本应用注册的实例
+func (c *Client) DeregisterServiceByName(name string, meta map[string]string) ([]string, error) {
+	if name == "" {
+		return nil, fmt.Errorf("service name cannot be empty")
+	}
+
+	services, err := c.client.Agent().Services()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list agent services: %v", err)
+	}
+
+	var ids []string
+	for id, svc := range services {
+		if svc.Service != name {
+			continue
+		}
+		matched := true
+		for k, v := range meta {
+			if svc.Meta[k] != v {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	var deregistered []string
+	for _, id := range ids {
+		if err := c.DeregisterService(id); err != nil {
+			return deregistered, err
+		}
+		deregistered = append(deregistered, id)
+	}
+
+	c.logger.Printf("Deregistered %d instances of service %s", len(deregistered), name)
+	return deregistered, nil
+}
+
 // GetService 获取服务实例
-func (c *Client) GetService(name string, tag string) ([]*api.ServiceEntry, error) {
-	services, err := c.GetHealthyServices(name)
+func (c *Client) GetService(name string, tag string, opts ...QueryOption) ([]*api.ServiceEntry, error) {
+	services, err := c.GetHealthyServices(name, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -113,8 +312,8 @@ func (c *Client) GetService(name string, tag string) ([]*api.ServiceEntry, error
 }
 
 // GetAllServices 获取所有服务
-func (c *Client) GetAllServices() (map[string][]string, error) {
-	services, _, err := c.client.Catalog().Services(nil)
+func (c *Client) GetAllServices(opts ...QueryOption) (map[string][]string, error) {
+	services, _, err := c.client.Catalog().Services(c.queryOptions(opts...))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get services: %v", err)
 	}
@@ -0,0 +1,150 @@
+package consul
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// serviceFile 是服务定义文件的结构，时间字段使用"10s"格式的字符串
+type serviceFile struct {
+	Name    string            `json:"name" yaml:"name"`
+	ID      string            `json:"id" yaml:"id"`
+	Tags    []string          `json:"tags" yaml:"tags"`
+	Address string            `json:"address" yaml:"address"`
+	Port    int               `json:"port" yaml:"port"`
+	Meta    map[string]string `json:"meta" yaml:"meta"`
+	Checks  []checkFile       `json:"checks" yaml:"checks"`
+}
+
+// checkFile 是服务定义文件中健康检查的结构
+type checkFile struct {
+	HTTP            string              `json:"http" yaml:"http"`
+	TCP             string              `json:"tcp" yaml:"tcp"`
+	Interval        string              `json:"interval" yaml:"interval"`
+	Timeout         string              `json:"timeout" yaml:"timeout"`
+	DeregisterAfter string              `json:"deregister_after" yaml:"deregister_after"`
+	TLSSkipVerify   bool                `json:"tls_skip_verify" yaml:"tls_skip_verify"`
+	Method          string              `json:"method" yaml:"method"`
+	Header          map[string][]string `json:"header" yaml:"header"`
+
+	ID   string `json:"id" yaml:"id"`
+	Name string `json:"name" yaml:"name"`
+	TTL  string `json:"ttl" yaml:"ttl"`
+
+	Args              []string `json:"args" yaml:"args"`
+	DockerContainerID string   `json:"docker_container_id" yaml:"docker_container_id"`
+	Shell             string   `json:"shell" yaml:"shell"`
+
+	AliasService string `json:"alias_service" yaml:"alias_service"`
+	AliasNode    string `json:"alias_node" yaml:"alias_node"`
+}
+
+// LoadServiceConfig 从YAML或JSON文件加载服务定义，
+// 文件内容中的${VAR}和${VAR:-default}会先替换为对应的环境变量
+func LoadServiceConfig(path string) (*ServiceConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service config: %v", err)
+	}
+
+	var format string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		format = "yaml"
+	case ".json":
+		format = "json"
+	default:
+		return nil, fmt.Errorf("unsupported service config format: %s", path)
+	}
+
+	return ParseServiceConfig(data, format)
+}
+
+// ParseServiceConfig 解析YAML或JSON格式的服务定义，format取值为yaml或json
+func ParseServiceConfig(data []byte, format string) (*ServiceConfig, error) {
+	expanded := expandEnv(string(data))
+
+	var file serviceFile
+	switch format {
+	case "yaml", "yml":
+		if err := yaml.Unmarshal([]byte(expanded), &file); err != nil {
+			return nil, fmt.Errorf("failed to parse service config: %v", err)
+		}
+	case "json":
+		if err := json.Unmarshal([]byte(expanded), &file); err != nil {
+			return nil, fmt.Errorf("failed to parse service config: %v", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported service config format: %s", format)
+	}
+
+	cfg := &ServiceConfig{
+		Name:    file.Name,
+		ID:      file.ID,
+		Tags:    file.Tags,
+		Address: file.Address,
+		Port:    file.Port,
+		Meta:    file.Meta,
+	}
+
+	for i, fc := range file.Checks {
+		check := &CheckConfig{
+			HTTP:              fc.HTTP,
+			TCP:               fc.TCP,
+			TLSSkipVerify:     fc.TLSSkipVerify,
+			Method:            fc.Method,
+			Header:            fc.Header,
+			ID:                fc.ID,
+			Name:              fc.Name,
+			Args:              fc.Args,
+			DockerContainerID: fc.DockerContainerID,
+			Shell:             fc.Shell,
+			AliasService:      fc.AliasService,
+			AliasNode:         fc.AliasNode,
+		}
+
+		var err error
+		if check.Interval, err = parseDuration(fc.Interval); err != nil {
+			return nil, fmt.Errorf("invalid interval in check %d: %v", i, err)
+		}
+		if check.Timeout, err = parseDuration(fc.Timeout); err != nil {
+			return nil, fmt.Errorf("invalid timeout in check %d: %v", i, err)
+		}
+		if check.DeregisterAfter, err = parseDuration(fc.DeregisterAfter); err != nil {
+			return nil, fmt.Errorf("invalid deregister_after in check %d: %v", i, err)
+		}
+		if check.TTL, err = parseDuration(fc.TTL); err != nil {
+			return nil, fmt.Errorf("invalid ttl in check %d: %v", i, err)
+		}
+		cfg.Checks = append(cfg.Checks, check)
+	}
+
+	return cfg, nil
+}
+
+// expandEnv 替换${VAR}、$VAR和${VAR:-default}形式的环境变量引用
+func expandEnv(s string) string {
+	return os.Expand(s, func(name string) string {
+		if key, def, ok := strings.Cut(name, ":-"); ok {
+			if value, found := os.LookupEnv(key); found && value != "" {
+				return value
+			}
+			return def
+		}
+		return os.Getenv(name)
+	})
+}
+
+// parseDuration 解析时间字符串，空字符串返回0
+func parseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(s)
+}
@@ -0,0 +1,60 @@
+package consul
+
+import (
+	"io"
+	"math/rand"
+	"strings"
+)
+
+// WithShadowTraffic 将sampleRate比例（0~1）的请求异步镜像到影子部署，影子响应会被忽略。
+// target为服务名时镜像到该服务；以"tag:"开头时镜像到本服务中带该标签的实例，
+// 这些实例不再参与正常流量
+func WithShadowTraffic(target string, sampleRate float64) InvokerOption {
+	return func(i *ServiceInvoker) {
+		i.shadowTarget = target
+		i.shadowRate = sampleRate
+	}
+}
+
+// initShadow 根据影子流量配置创建影子调用器
+func (i *ServiceInvoker) initShadow() {
+	if i.shadowTarget == "" || i.shadowRate <= 0 {
+		return
+	}
+
+	opts := []InvokerOption{
+		WithInvokeTimeout(i.timeout),
+		WithRetry(0, 0),
+	}
+	service := i.shadowTarget
+	if tag, ok := strings.CutPrefix(i.shadowTarget, "tag:"); ok {
+		i.shadowTag = tag
+		service = i.serviceName
+		opts = append(opts, WithTags([]string{tag}))
+	}
+	i.shadow = i.client.NewServiceInvoker(service, opts...)
+}
+
+// mirror 按采样比例异步发送影子请求
+func (i *ServiceInvoker) mirror(method, path string, headers map[string]string, body []byte) {
+	if i.shadow == nil || rand.Float64() >= i.shadowRate {
+		return
+	}
+
+	// 复制请求数据，避免与主请求共享
+	h := make(map[string]string, len(headers))
+	for k, v := range headers {
+		h[k] = v
+	}
+	b := append([]byte(nil), body...)
+
+	go func() {
+		resp, err := i.shadow.Call(method, path, h, b)
+		if err != nil {
+			i.client.logger.Printf("Shadow request to %s failed: %v", i.shadowTarget, err)
+			return
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}()
+}
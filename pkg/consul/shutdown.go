@@ -0,0 +1,73 @@
+package consul
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// goWorker 在后台运行长期任务并计数，Shutdown时等待其退出
+func (c *Client) goWorker(name string, fn func()) {
+	c.workersMu.Lock()
+	if c.workers == nil {
+		c.workers = make(map[string]int)
+	}
+	c.workers[name]++
+	c.workersMu.Unlock()
+
+	go func() {
+		defer func() {
+			c.workersMu.Lock()
+			if c.workers[name]--; c.workers[name] <= 0 {
+				delete(c.workers, name)
+			}
+			c.workersMu.Unlock()
+		}()
+		fn()
+	}()
+}
+
+// ActiveWorkers 返回仍在运行的后台任务及其数量，如配置监听、watch plan、健康上报和回收器
+func (c *Client) ActiveWorkers() map[string]int {
+	c.workersMu.Lock()
+	defer c.workersMu.Unlock()
+	return maps.Clone(c.workers)
+}
+
+// Shutdown 优雅关闭客户端：deregister为true时先注销通过本客户端注册的服务，
+// 然后停止所有后台任务并等待其退出，ctx到期时返回仍未退出的任务
+func (c *Client) Shutdown(ctx context.Context, deregister bool) error {
+	var errs []string
+	if deregister {
+		if err := c.DeregisterAll(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if c.cancel != nil {
+		c.cancel()
+	}
+
+	for len(c.ActiveWorkers()) > 0 && ctx.Err() == nil {
+		sleepContext(ctx, 10*time.Millisecond)
+	}
+	if remaining := c.ActiveWorkers(); len(remaining) > 0 {
+		names := make([]string, 0, len(remaining))
+		for _, name := range slices.Sorted(maps.Keys(remaining)) {
+			names = append(names, fmt.Sprintf("%s (%d)", name, remaining[name]))
+		}
+		c.logger.Printf("Consul client shutdown incomplete, workers still running: %s", strings.Join(names, ", "))
+		errs = append(errs, "workers still running: "+strings.Join(names, ", "))
+	}
+
+	c.closeTransports()
+	c.endLogin()
+	c.logger.Println("Consul client shut down")
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to shut down cleanly: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package consul
+
+import (
+	"fmt"
+	"io"
+)
+
+// SnapshotSave 保存Consul集群快照并写入w，返回写入的字节数
+func (c *Client) SnapshotSave(w io.Writer) (int64, error) {
+	if w == nil {
+		return 0, fmt.Errorf("writer cannot be nil")
+	}
+
+	snap, _, err := c.client.Snapshot().Save(nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save snapshot: %v", err)
+	}
+	defer snap.Close()
+
+	n, err := io.Copy(w, snap)
+	if err != nil {
+		return n, fmt.Errorf("failed to write snapshot: %v", err)
+	}
+
+	c.logger.Printf("Snapshot saved: %d bytes", n)
+	return n, nil
+}
+
+// SnapshotRestore 从r读取快照并恢复Consul集群状态
+func (c *Client) SnapshotRestore(r io.Reader) error {
+	if r == nil {
+		return fmt.Errorf("reader cannot be nil")
+	}
+
+	if err := c.client.Snapshot().Restore(nil, r); err != nil {
+		return fmt.Errorf("failed to restore snapshot: %v", err)
+	}
+
+	c.logger.Println("Snapshot restored")
+	return nil
+}
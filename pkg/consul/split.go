@@ -0,0 +1,93 @@
+package consul
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"net/http"
+	"sort"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// WithTrafficSplit 按权重在实例分组之间分配流量，分组默认按标签匹配，
+// 例如：{"stable": 90, "canary": 10} 表示10%的调用发往带canary标签的实例
+func WithTrafficSplit(weights map[string]int) InvokerOption {
+	return func(i *ServiceInvoker) {
+		i.splitWeights = weights
+	}
+}
+
+// WithTrafficSplitMetaKey 设置流量分组按指定元数据字段的值匹配，而不是按标签匹配
+func WithTrafficSplitMetaKey(key string) InvokerOption {
+	return func(i *ServiceInvoker) {
+		i.splitMetaKey = key
+	}
+}
+
+// WithTrafficSplitHashHeader 设置按请求头的值哈希确定分组，
+// 同一个值（如用户ID）总是落入同一分组；请求头缺失时随机分配
+func WithTrafficSplitHashHeader(header string) InvokerOption {
+	return func(i *ServiceInvoker) {
+		i.splitHashHeader = http.CanonicalHeaderKey(header)
+	}
+}
+
+// applyTrafficSplit 根据流量分配权重选出本次调用的实例分组，
+// 选中的分组没有实例时回退到全部实例
+func (i *ServiceInvoker) applyTrafficSplit(services []*api.ServiceEntry, headers map[string]string) []*api.ServiceEntry {
+	if len(i.splitWeights) == 0 {
+		return services
+	}
+
+	// 按分组名排序，保证哈希分配结果稳定
+	groups := make([]string, 0, len(i.splitWeights))
+	total := 0
+	for group, weight := range i.splitWeights {
+		if weight <= 0 {
+			continue
+		}
+		groups = append(groups, group)
+		total += weight
+	}
+	if total == 0 {
+		return services
+	}
+	sort.Strings(groups)
+
+	var bucket int
+	if key, ok := headers[i.splitHashHeader]; ok && i.splitHashHeader != "" && key != "" {
+		h := fnv.New32a()
+		h.Write([]byte(key))
+		bucket = int(h.Sum32() % uint32(total))
+	} else {
+		bucket = rand.Intn(total)
+	}
+
+	var selected string
+	for _, group := range groups {
+		bucket -= i.splitWeights[group]
+		if bucket < 0 {
+			selected = group
+			break
+		}
+	}
+
+	var matched []*api.ServiceEntry
+	for _, service := range services {
+		if i.inSplitGroup(service.Service, selected) {
+			matched = append(matched, service)
+		}
+	}
+	if len(matched) == 0 {
+		return services
+	}
+	return matched
+}
+
+// inSplitGroup 判断实例是否属于指定的流量分组
+func (i *ServiceInvoker) inSplitGroup(service *api.AgentService, group string) bool {
+	if i.splitMetaKey != "" {
+		return service.Meta[i.splitMetaKey] == group
+	}
+	return containsAll(service.Tags, []string{group})
+}
@@ -0,0 +1,42 @@
+// subscribe.go
+package consul
+
+import (
+	"github.com/hashicorp/consul/api"
+)
+
+// Subscribe 订阅服务的健康实例，每次变化时推送完整的实例列表（只包含带有全部tags的实例），
+// 便于自定义负载均衡、gRPC resolver或代理消费服务发现结果。
+// 通道只保留最新的列表，消费慢时旧列表会被丢弃；调用stop或客户端关闭后通道被关闭
+func (c *Client) Subscribe(service string, tags []string) (<-chan []Instance, func(), error) {
+	ch := make(chan []Instance, 1)
+	h, err := c.WatchService(service, true, func(entries []*api.ServiceEntry) {
+		var matched []*api.ServiceEntry
+		for _, entry := range entries {
+			if containsAll(entry.Service.Tags, tags) {
+				matched = append(matched, entry)
+			}
+		}
+		instances := newInstances(matched)
+
+		// 丢弃未被消费的旧列表
+		select {
+		case ch <- instances:
+		default:
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- instances
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	go func() {
+		<-h.Done()
+		close(ch)
+	}()
+	return ch, h.Stop, nil
+}
@@ -0,0 +1,58 @@
+package consul
+
+import (
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// WithKVPrefixToken 为指定KV前缀设置ACL Token，读写该前缀下的键时使用，
+// 多个前缀匹配时取最长前缀，单次调用的WithQueryToken/WithWriteToken优先
+func WithKVPrefixToken(prefix, token string) Option {
+	return func(c *Config) {
+		if c.kvTokens == nil {
+			c.kvTokens = make(map[string]string)
+		}
+		c.kvTokens[prefix] = token
+	}
+}
+
+// kvToken 返回键匹配的最长前缀对应的Token，没有匹配时返回空字符串
+func (c *Client) kvToken(key string) string {
+	var token string
+	matched := -1
+	for prefix, t := range c.config.kvTokens {
+		if strings.HasPrefix(key, prefix) && len(prefix) > matched {
+			token, matched = t, len(prefix)
+		}
+	}
+	return token
+}
+
+// kvQueryOptions 构造KV查询的QueryOptions，未显式指定Token时使用键前缀对应的Token
+func (c *Client) kvQueryOptions(key string, opts ...QueryOption) *api.QueryOptions {
+	q := c.queryOptions(opts...)
+	if q.Token == "" {
+		q.Token = c.kvToken(key)
+	}
+	return q
+}
+
+// kvWriteOptions 构造KV写操作的WriteOptions，未显式指定Token时使用键前缀对应的Token
+func (c *Client) kvWriteOptions(key string, opts ...WriteOption) *api.WriteOptions {
+	w := c.writeOptions(opts...)
+	if w.Token == "" {
+		w.Token = c.kvToken(key)
+	}
+	return w
+}
+
+// serviceToken 返回通过本客户端注册的服务配置中的Token
+func (c *Client) serviceToken(serviceID string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if cfg, ok := c.services[serviceID]; ok {
+		return cfg.Token
+	}
+	return ""
+}
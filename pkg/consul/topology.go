@@ -0,0 +1,116 @@
+package consul
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// Topology 是服务之间的依赖关系图，边的方向为"调用方 -> 被依赖方"
+type Topology struct {
+	deps map[string]map[string]bool // 服务到其依赖的服务集合
+}
+
+// BuildTopology 根据服务元数据中的依赖声明和Connect intentions构建依赖关系图。
+// metaKey为依赖声明所在的元数据字段，值为逗号分隔的服务名，为空时使用depends_on
+func (c *Client) BuildTopology(metaKey string) (*Topology, error) {
+	if metaKey == "" {
+		metaKey = "depends_on"
+	}
+
+	services, err := c.GetAllServices()
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Topology{deps: make(map[string]map[string]bool)}
+	for name := range services {
+		t.addNode(name)
+
+		instances, _, err := c.client.Catalog().Service(name, "", c.queryOptions())
+		if err != nil {
+			return nil, fmt.Errorf("failed to get service %s: %v", name, err)
+		}
+		for _, inst := range instances {
+			for _, dep := range strings.Split(inst.ServiceMeta[metaKey], ",") {
+				if dep = strings.TrimSpace(dep); dep != "" {
+					t.addEdge(name, dep)
+				}
+			}
+		}
+	}
+
+	// Connect intentions中允许的调用同样视为依赖，未启用Connect或无权限时忽略
+	intentions, _, err := c.client.Connect().Intentions(c.queryOptions())
+	if err != nil {
+		c.logger.Printf("Skipping intentions in topology: %v", err)
+		return t, nil
+	}
+	for _, ixn := range intentions {
+		if ixn.Action == api.IntentionActionDeny || ixn.SourceName == "*" || ixn.DestinationName == "*" {
+			continue
+		}
+		t.addEdge(ixn.SourceName, ixn.DestinationName)
+	}
+
+	return t, nil
+}
+
+// Services 返回图中的所有服务
+func (t *Topology) Services() []string {
+	return slices.Sorted(maps.Keys(t.deps))
+}
+
+// Dependencies 返回指定服务直接依赖的服务
+func (t *Topology) Dependencies(name string) []string {
+	return slices.Sorted(maps.Keys(t.deps[name]))
+}
+
+// Dependents 返回直接依赖指定服务的服务
+func (t *Topology) Dependents(name string) []string {
+	var result []string
+	for svc, deps := range t.deps {
+		if deps[name] {
+			result = append(result, svc)
+		}
+	}
+	slices.Sort(result)
+	return result
+}
+
+// ImpactOf 返回指定服务故障时直接或间接受影响的所有服务
+func (t *Topology) ImpactOf(name string) []string {
+	visited := map[string]bool{name: true}
+	queue := []string{name}
+	var result []string
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, dependent := range t.Dependents(current) {
+			if !visited[dependent] {
+				visited[dependent] = true
+				result = append(result, dependent)
+				queue = append(queue, dependent)
+			}
+		}
+	}
+	slices.Sort(result)
+	return result
+}
+
+// addNode 添加服务节点
+func (t *Topology) addNode(name string) {
+	if _, ok := t.deps[name]; !ok {
+		t.deps[name] = make(map[string]bool)
+	}
+}
+
+// addEdge 添加依赖关系
+func (t *Topology) addEdge(from, to string) {
+	t.addNode(from)
+	t.addNode(to)
+	t.deps[from][to] = true
+}
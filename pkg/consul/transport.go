@@ -0,0 +1,44 @@
+// transport.go
+package consul
+
+import (
+	"net/http"
+	"time"
+)
+
+// WithTransport 为调用器指定独立的传输层，不使用客户端共享的连接池，
+// 例如需要自定义TLS配置的下游，此时WithConnectTimeout不再生效
+func WithTransport(transport http.RoundTripper) InvokerOption {
+	return func(i *ServiceInvoker) {
+		i.transport = transport
+	}
+}
+
+// sharedTransport 返回按建连超时共享的传输层，同一客户端创建的调用器复用连接池，
+// 减少连接数和TLS握手
+func (c *Client) sharedTransport(connectTimeout time.Duration) http.RoundTripper {
+	c.transportMu.Lock()
+	defer c.transportMu.Unlock()
+
+	if c.transports == nil {
+		c.transports = make(map[time.Duration]http.RoundTripper)
+	}
+	transport, ok := c.transports[connectTimeout]
+	if !ok {
+		transport = newTransport(connectTimeout)
+		c.transports[connectTimeout] = transport
+	}
+	return transport
+}
+
+// closeTransports 关闭共享传输层的空闲连接
+func (c *Client) closeTransports() {
+	c.transportMu.Lock()
+	defer c.transportMu.Unlock()
+
+	for _, transport := range c.transports {
+		if t, ok := transport.(interface{ CloseIdleConnections() }); ok {
+			t.CloseIdleConnections()
+		}
+	}
+}
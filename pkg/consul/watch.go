@@ -2,8 +2,11 @@
 package consul
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"reflect"
 	"time"
 
 	"github.com/hashicorp/consul/api"
@@ -11,66 +14,474 @@ import (
 
 // WatchOptions 监听选项
 type WatchOptions struct {
-	WaitTime  time.Duration // 等待时间
-	RetryTime time.Duration // 重试间隔
+	WaitTime       time.Duration // 等待时间
+	RetryTime      time.Duration // 重试间隔
+	VerifyChecksum bool          // 是否校验PutConfigChecked写入的SHA-256校验和，校验失败的更新会被忽略
+	JSONSchema     []byte        // 配置需符合的JSON Schema，不符合的更新会被忽略并回调OnError
+
+	Debounce    time.Duration // 静默时间，窗口内的多次变更只应用最后一次
+	MinInterval time.Duration // 两次应用配置之间的最小间隔，用于批量导入时限流
+
+	Initial  InitialLoadPolicy // 启动时键不存在或无法解析的处理方式
+	Defaults interface{}       // InitialDefaults使用的默认值；WatchConfigSet中为键到默认值的map[string]interface{}
+
+	OnError func(key string, err error) // 查询或解析失败时的回调，用于监控告警
+
+	OnDelete     func(key string) // 监听的键被删除时的回调
+	DeletePolicy DeletePolicy     // 键被删除后如何处理已加载的配置
+
+	// 定期放弃阻塞索引重新全量读取的间隔，用于Agent快照恢复等情况下漏掉更新后自愈，
+	// 默认10分钟，负数表示不重新读取
+	ResyncInterval time.Duration
+}
+
+// defaultResyncInterval 监听默认的全量重新读取间隔
+const defaultResyncInterval = 10 * time.Minute
+
+// DeletePolicy 定义监听的键被删除后的处理方式
+type DeletePolicy int
+
+const (
+	// DeleteKeepLast 保留最后一次加载的配置（默认）
+	DeleteKeepLast DeletePolicy = iota
+	// DeleteZero 将配置重置为零值
+	DeleteZero
+	// DeleteDefaults 将配置重置为Defaults
+	DeleteDefaults
+)
+
+// InitialLoadPolicy 定义启动时加载配置失败的处理方式
+type InitialLoadPolicy int
+
+const (
+	// InitialOptional 键不存在时保持结构体原值，解析失败返回错误（默认）
+	InitialOptional InitialLoadPolicy = iota
+	// InitialRequire 键不存在或解析失败时立即返回错误
+	InitialRequire
+	// InitialDefaults 键不存在或解析失败时使用Defaults
+	InitialDefaults
+	// InitialWait 阻塞等待键出现并解析成功，客户端关闭时返回错误
+	InitialWait
+)
+
+// defaultWatchOptions 返回默认监听选项
+func defaultWatchOptions() *WatchOptions {
+	return &WatchOptions{
+		WaitTime:  time.Second * 10,
+		RetryTime: time.Second,
+	}
+}
+
+// watchState 记录单个配置监听的运行状态
+type watchState struct {
+	key     string    // 监听的KV键
+	started time.Time // 监听启动时间
+
+	lastSuccess time.Time // 最近一次成功查询的时间
+	failures    int       // 连续失败次数
+	lastError   string    // 最近一次错误
+
+	lastIndex  uint64    // 最近一次查询的索引
+	lastUpdate time.Time // 最近一次收到变更的时间
+	updates    uint64    // 收到的变更次数
+	errors     uint64    // 累计错误次数
+	resyncs    uint64    // 全量重新读取的次数
+
+	paused  bool               // 暂停期间变更不应用到目标结构体
+	refresh bool               // 下一次查询放弃阻塞索引并强制应用
+	cancel  context.CancelFunc // 中断当前的阻塞查询
+	wake    chan struct{}      // 恢复时通知合并协程应用搁置的变更
+
+	ctx  context.Context    // 监听的生命周期，客户端关闭或StopWatch时结束
+	stop context.CancelFunc // 停止同一次WatchConfig/WatchConfigSet启动的所有监听
 }
 
 // WatchConfig 监听配置并自动解析到结构体
 func (c *Client) WatchConfig(key string, config interface{}, opts *WatchOptions) error {
+	return c.watchConfig(c.ctx, key, config, opts)
+}
+
+// watchConfig 同WatchConfig，ctx结束时停止初始加载（包括InitialWait的等待）并返回错误，
+// 不影响加载完成后启动的监听
+func (c *Client) watchConfig(ctx context.Context, key string, config interface{}, opts *WatchOptions) error {
 	if key == "" {
 		return fmt.Errorf("key cannot be empty")
 	}
 
 	if opts == nil {
-		opts = &WatchOptions{
-			WaitTime:  time.Second * 10,
-			RetryTime: time.Second,
+		opts = defaultWatchOptions()
+	}
+	if opts.JSONSchema != nil {
+		if _, err := CompileSchema(opts.JSONSchema); err != nil {
+			return err
 		}
 	}
 
-	// 先获取初始配置
-	pair, _, err := c.client.KV().Get(key, nil)
+	// 先获取初始配置，客户端关闭时同样中断
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer context.AfterFunc(c.ctx, cancel)()
+	if err := c.loadInitial(ctx, key, config, opts.Defaults, opts); err != nil {
+		return err
+	}
+
+	// 启动监听
+	c.startConfigWatches(map[string]interface{}{key: config}, map[string]interface{}{key: opts.Defaults}, nil, opts)
+
+	return nil
+}
+
+// trackWatch 登记活跃的配置监听
+func (c *Client) trackWatch(key string) *watchState {
+	state := &watchState{key: key, started: time.Now()}
+	c.mu.Lock()
+	c.watches[key] = state
+	c.mu.Unlock()
+	return state
+}
+
+// untrackWatch 移除监听登记，同一键已被新的监听覆盖时保留新的登记
+func (c *Client) untrackWatch(state *watchState) {
+	c.mu.Lock()
+	if c.watches[state.key] == state {
+		delete(c.watches, state.key)
+	}
+	c.mu.Unlock()
+}
+
+// PauseWatch 暂停将key的变更应用到目标结构体，用于故障处理期间冻结配置；
+// 监听继续运行，期间的变更被搁置，ResumeWatch时应用最新的一次
+func (c *Client) PauseWatch(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	state, ok := c.watches[key]
+	if !ok {
+		return fmt.Errorf("no active watch for key %s", key)
+	}
+	state.paused = true
+	return nil
+}
+
+// ResumeWatch 恢复应用key的变更，暂停期间有变更时立即应用
+func (c *Client) ResumeWatch(key string) error {
+	c.mu.Lock()
+	state, ok := c.watches[key]
+	if ok {
+		state.paused = false
+	}
+	c.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("no active watch for key %s", key)
+	}
+
+	select {
+	case state.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// RefreshWatch 中断key当前的阻塞查询立即重新读取，即使值未变化也重新解析并触发回调，
+// 用于手工修改或调整校验规则后强制重新加载；暂停期间读取的值同样被搁置
+func (c *Client) RefreshWatch(key string) error {
+	c.mu.Lock()
+	state, ok := c.watches[key]
+	var cancel context.CancelFunc
+	if ok {
+		state.refresh = true
+		cancel = state.cancel
+	}
+	c.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("no active watch for key %s", key)
+	}
+
+	if cancel != nil {
+		cancel()
+	}
+	return nil
+}
+
+// StopWatch 停止key的监听，已加载的配置保持不变；通过WatchConfigSet一起启动的键共用一个监听组，会一并停止
+func (c *Client) StopWatch(key string) error {
+	c.mu.RLock()
+	state, ok := c.watches[key]
+	c.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("no active watch for key %s", key)
+	}
+	state.stop()
+	return nil
+}
+
+// watchPaused 返回监听是否处于暂停状态
+func (c *Client) watchPaused(state *watchState) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return state.paused
+}
+
+// reportWatch 记录监听结果，err不为nil时回调OnError
+func (c *Client) reportWatch(state *watchState, opts *WatchOptions, err error) {
+	c.mu.Lock()
 	if err != nil {
-		return fmt.Errorf("failed to get initial config: %v", err)
+		state.failures++
+		state.errors++
+		state.lastError = err.Error()
+	} else {
+		state.lastSuccess = time.Now()
+		state.failures = 0
 	}
-	if pair != nil {
-		if err := json.Unmarshal(pair.Value, config); err != nil {
-			return fmt.Errorf("failed to parse initial config: %v", err)
-		}
+	c.mu.Unlock()
+
+	if err != nil && opts.OnError != nil {
+		opts.OnError(state.key, err)
 	}
+}
 
-	// 启动监听
-	go func() {
-		var waitIndex uint64
-		for {
-			select {
-			case <-c.ctx.Done():
-				c.logger.Printf("Stopping watch for key: %s", key)
-				return
-			default:
-				pair, meta, err := c.client.KV().Get(key, &api.QueryOptions{
-					WaitIndex: waitIndex,
-					WaitTime:  opts.WaitTime,
-				})
-
-				if err != nil {
-					c.logger.Printf("Error watching key %s: %v", key, err)
-					time.Sleep(opts.RetryTime)
-					continue
-				}
+// watchLoop 对键执行阻塞查询直到客户端关闭或监听被停止，值发生变化时调用onChange，
+// 键被删除时以nil调用onChange。索引处理遵循Consul阻塞查询的约定：
+// 索引回退（如快照恢复后）时重置为0重新读取，索引为0时按1处理以避免忙等
+func (c *Client) watchLoop(state *watchState, opts *WatchOptions, onChange func(pair *api.KVPair)) {
+	key := state.key
+	backoff := c.config.backoffPolicy(opts.RetryTime)
+	failures := 0
+	var waitIndex, modifyIndex uint64
+	var lastValue []byte
+	exists := false
+
+	resyncInterval := opts.ResyncInterval
+	if resyncInterval == 0 {
+		resyncInterval = defaultResyncInterval
+	}
+	nextResync := time.Now().Add(resyncInterval)
+	for {
+		select {
+		case <-state.ctx.Done():
+			c.logger.Printf("Stopping watch for key: %s", key)
+			return
+		default:
+			// 到期时放弃阻塞索引立即全量读取，比较值以发现索引未变但内容不同的情况
+			resyncing := false
+			if resyncInterval > 0 && time.Now().After(nextResync) {
+				resyncing = true
+				waitIndex = 0
+				nextResync = time.Now().Add(resyncInterval)
+				c.mu.Lock()
+				state.resyncs++
+				c.mu.Unlock()
+			}
+
+			// 绑定监听的上下文，关闭、StopWatch或RefreshWatch时立即中断阻塞查询
+			ctx, cancel := context.WithCancel(state.ctx)
+			c.mu.Lock()
+			state.cancel = cancel
+			if state.refresh {
+				state.refresh = false
+				waitIndex = 0
+				modifyIndex = 0
+			}
+			c.mu.Unlock()
+
+			q := c.kvQueryOptions(key).WithContext(ctx)
+			q.WaitIndex = waitIndex
+			q.WaitTime = opts.WaitTime
+			pair, meta, err := c.client.KV().Get(key, q)
+			interrupted := ctx.Err() != nil
+			cancel()
+			if err != nil && interrupted {
+				continue
+			}
+			c.reportWatch(state, opts, err)
+
+			if err != nil {
+				delay := backoff.Delay(failures)
+				failures++
+				c.logger.Printf("Error watching key %s, retrying in %v: %v", key, delay, err)
+				sleepContext(state.ctx, delay)
+				continue
+			}
+			if failures > 0 {
+				c.emit(Event{Type: EventConsulReconnected, Key: key, Message: fmt.Sprintf("watch recovered after %d failures", failures)})
+			}
+			failures = 0
+
+			index := meta.LastIndex
+			if index < waitIndex {
+				c.logger.Printf("Index went backwards for key %s (%d < %d), resetting", key, index, waitIndex)
+				waitIndex = 0
+				modifyIndex = 0
+				continue
+			}
+			if index == 0 {
+				index = 1
+			}
+			waitIndex = index
 
-				if pair != nil && meta.LastIndex > waitIndex {
-					if err := json.Unmarshal(pair.Value, config); err != nil {
-						c.logger.Printf("Error parsing config for %s: %v", key, err)
-						continue
-					}
-					c.logger.Printf("Config updated: %s", key)
+			changed := false
+			switch {
+			case pair != nil && (pair.ModifyIndex != modifyIndex || resyncing && !bytes.Equal(pair.Value, lastValue)):
+				if resyncing && pair.ModifyIndex == modifyIndex {
+					c.logger.Printf("Resync found missed update for key %s", key)
 				}
+				modifyIndex = pair.ModifyIndex
+				lastValue = pair.Value
+				exists = true
+				changed = true
+				onChange(pair)
+				c.emit(Event{Type: EventWatchUpdated, Key: key})
+			case pair == nil && exists:
+				modifyIndex = 0
+				lastValue = nil
+				exists = false
+				changed = true
+				c.logger.Printf("Config deleted: %s", key)
+				onChange(nil)
+				c.emit(Event{Type: EventWatchUpdated, Key: key, Message: "deleted"})
+			}
 
-				waitIndex = meta.LastIndex
+			c.mu.Lock()
+			state.lastIndex = index
+			if changed {
+				state.updates++
+				state.lastUpdate = time.Now()
 			}
+			c.mu.Unlock()
+		}
+	}
+}
+
+// loadInitial 按初始加载策略获取配置，ctx结束时中断查询
+func (c *Client) loadInitial(ctx context.Context, key string, config, defaults interface{}, opts *WatchOptions) error {
+	if opts.Initial == InitialWait {
+		return c.waitInitial(ctx, key, config, opts)
+	}
+
+	pair, _, err := c.client.KV().Get(key, c.kvQueryOptions(key).WithContext(ctx))
+	if err != nil {
+		if cacheErr := c.loadCache(key, config); cacheErr == nil {
+			c.logger.Printf("Failed to get initial config %s, loaded from local cache: %v", key, err)
+			return nil
+		}
+		return fmt.Errorf("failed to get initial config %s: %v", key, err)
+	}
+	if pair == nil {
+		switch opts.Initial {
+		case InitialRequire:
+			return fmt.Errorf("initial config %s not found", key)
+		case InitialDefaults:
+			c.logger.Printf("Initial config %s not found, using defaults", key)
+			return applyDefaults(config, defaults)
+		}
+		return nil
+	}
+
+	if err := c.decodeConfig(pair, config, opts); err != nil {
+		if opts.Initial == InitialDefaults {
+			c.logger.Printf("Failed to parse initial config %s, using defaults: %v", key, err)
+			return applyDefaults(config, defaults)
+		}
+		return fmt.Errorf("failed to parse initial config %s: %v", key, err)
+	}
+	c.saveCache(key, pair.Value)
+	return nil
+}
+
+// waitInitial 通过阻塞查询等待键出现并解析成功，直到客户端关闭或ctx结束
+func (c *Client) waitInitial(ctx context.Context, key string, config interface{}, opts *WatchOptions) error {
+	backoff := c.config.backoffPolicy(opts.RetryTime)
+	failures := 0
+	var waitIndex uint64
+	for {
+		if c.ctx.Err() != nil {
+			return fmt.Errorf("client closed while waiting for initial config %s", key)
+		}
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("stopped waiting for initial config %s: %w", key, err)
+		}
+
+		q := c.kvQueryOptions(key).WithContext(ctx)
+		q.WaitIndex = waitIndex
+		q.WaitTime = opts.WaitTime
+		pair, meta, err := c.client.KV().Get(key, q)
+		if err != nil {
+			delay := backoff.Delay(failures)
+			failures++
+			c.logger.Printf("Error waiting for initial config %s, retrying in %v: %v", key, delay, err)
+			sleepContext(ctx, delay)
+			continue
+		}
+		failures = 0
+		waitIndex = meta.LastIndex
+
+		if pair == nil {
+			c.logger.Printf("Waiting for initial config %s", key)
+			continue
 		}
-	}()
+		if err := c.decodeConfig(pair, config, opts); err != nil {
+			c.logger.Printf("Failed to parse initial config %s, waiting for update: %v", key, err)
+			continue
+		}
+		c.saveCache(key, pair.Value)
+		return nil
+	}
+}
 
+// applyDefaults 将默认值复制到目标结构体
+func applyDefaults(config, defaults interface{}) error {
+	if defaults == nil {
+		return fmt.Errorf("defaults are required")
+	}
+	data, err := json.Marshal(defaults)
+	if err != nil {
+		return fmt.Errorf("failed to marshal defaults: %v", err)
+	}
+	if err := json.Unmarshal(data, config); err != nil {
+		return fmt.Errorf("failed to apply defaults: %v", err)
+	}
 	return nil
 }
+
+// resetConfig 按删除策略处理已加载的配置，返回配置是否被修改
+func resetConfig(config, defaults interface{}, policy DeletePolicy) (bool, error) {
+	if policy == DeleteKeepLast {
+		return false, nil
+	}
+
+	v := reflect.ValueOf(config)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return false, fmt.Errorf("config must be a non-nil pointer")
+	}
+	v.Elem().Set(reflect.Zero(v.Elem().Type()))
+
+	if policy == DeleteDefaults {
+		if err := applyDefaults(config, defaults); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+// decodeConfig 按监听选项校验并解析配置
+func (c *Client) decodeConfig(pair *api.KVPair, config interface{}, opts *WatchOptions) error {
+	if opts.VerifyChecksum {
+		expected, err := c.fetchChecksum(pair.Key)
+		if err != nil {
+			return err
+		}
+		if err := c.verifyChecksum(pair.Key, pair.Value, expected); err != nil {
+			return err
+		}
+	}
+	// 在解析前校验，避免不合规的值部分写入目标结构体
+	if opts.JSONSchema != nil {
+		s, err := CompileSchema(opts.JSONSchema)
+		if err != nil {
+			return err
+		}
+		if err := s.Validate(pair.Value); err != nil {
+			return err
+		}
+	}
+	return c.applyConfigRules(pair.Key, pair.Value, config)
+}
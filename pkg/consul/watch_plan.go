@@ -0,0 +1,259 @@
+package consul
+
+import (
+	"fmt"
+	"maps"
+	"sync"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/api/watch"
+)
+
+// WatchHandle 是基于Consul watch plan的监听句柄
+type WatchHandle struct {
+	c       *Client
+	params  map[string]interface{} // 创建plan的参数，watch.Parse会修改参数，每次使用副本
+	handler func(interface{})
+	done    chan struct{}
+
+	mu         sync.Mutex
+	plan       *watch.Plan
+	stopped    bool
+	refreshing bool // Refresh触发的重启，plan结束后重新创建
+	paused     bool // 暂停期间不回调
+	held       bool // 暂停期间是否有被搁置的结果
+}
+
+// Stop 停止监听
+func (h *WatchHandle) Stop() {
+	h.mu.Lock()
+	h.stopped = true
+	plan := h.plan
+	h.mu.Unlock()
+	plan.Stop()
+}
+
+// Done 返回监听结束时关闭的通道
+func (h *WatchHandle) Done() <-chan struct{} {
+	return h.done
+}
+
+// Pause 暂停回调，用于故障处理期间冻结配置变更；监听继续运行，
+// 期间的结果被搁置，Resume时以最新状态回调一次
+func (h *WatchHandle) Pause() {
+	h.mu.Lock()
+	h.paused = true
+	h.mu.Unlock()
+}
+
+// Resume 恢复回调，暂停期间有变更时立即重新查询并以最新状态回调
+func (h *WatchHandle) Resume() {
+	h.mu.Lock()
+	held := h.paused && h.held
+	h.paused = false
+	h.held = false
+	h.mu.Unlock()
+	if held {
+		h.Refresh()
+	}
+}
+
+// Paused 返回监听是否处于暂停状态
+func (h *WatchHandle) Paused() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.paused
+}
+
+// Refresh 放弃当前的阻塞查询立即重新读取，即使数据未变化也会回调一次，
+// 用于手工修改后强制重新加载；暂停期间读取的结果同样被搁置
+func (h *WatchHandle) Refresh() {
+	h.mu.Lock()
+	if h.stopped {
+		h.mu.Unlock()
+		return
+	}
+	h.refreshing = true
+	plan := h.plan
+	h.mu.Unlock()
+	plan.Stop()
+}
+
+// newPlan 按参数创建watch plan
+func (h *WatchHandle) newPlan() (*watch.Plan, error) {
+	plan, err := watch.Parse(maps.Clone(h.params))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create watch plan: %v", err)
+	}
+	plan.HybridHandler = func(_ watch.BlockingParamVal, raw interface{}) {
+		h.mu.Lock()
+		if h.paused {
+			h.held = true
+			h.mu.Unlock()
+			return
+		}
+		h.mu.Unlock()
+		h.handler(raw)
+	}
+	return plan, nil
+}
+
+// run 运行plan直到停止，Refresh时以新的plan重新开始
+func (h *WatchHandle) run() {
+	for {
+		h.mu.Lock()
+		plan := h.plan
+		h.mu.Unlock()
+		if err := plan.RunWithClientAndLogger(h.c.client, h.c.logger); err != nil {
+			h.c.logger.Printf("Watch plan %v stopped with error: %v", h.params["type"], err)
+		}
+
+		h.mu.Lock()
+		restart := h.refreshing && !h.stopped && h.c.ctx.Err() == nil
+		h.refreshing = false
+		if restart {
+			next, err := h.newPlan()
+			if err != nil {
+				h.c.logger.Printf("Failed to refresh watch plan %v: %v", h.params["type"], err)
+				restart = false
+			} else {
+				h.plan = next
+			}
+		}
+		h.mu.Unlock()
+		if !restart {
+			return
+		}
+	}
+}
+
+// WatchKey 监听单个KV键，键被删除时回调参数为nil
+func (c *Client) WatchKey(key string, fn func(*api.KVPair)) (*WatchHandle, error) {
+	if fn == nil {
+		return nil, fmt.Errorf("watch handler cannot be nil")
+	}
+	if key == "" {
+		return nil, fmt.Errorf("key cannot be empty")
+	}
+	return c.runPlan(map[string]interface{}{"type": "key", "key": key}, func(raw interface{}) {
+		pair, _ := raw.(*api.KVPair)
+		fn(pair)
+	})
+}
+
+// WatchKeyPrefix 监听指定前缀下的所有KV
+func (c *Client) WatchKeyPrefix(prefix string, fn func(api.KVPairs)) (*WatchHandle, error) {
+	if fn == nil {
+		return nil, fmt.Errorf("watch handler cannot be nil")
+	}
+	if prefix == "" {
+		return nil, fmt.Errorf("prefix cannot be empty")
+	}
+	return c.runPlan(map[string]interface{}{"type": "keyprefix", "prefix": prefix}, func(raw interface{}) {
+		pairs, _ := raw.(api.KVPairs)
+		fn(pairs)
+	})
+}
+
+// WatchServices 监听服务目录，回调参数为服务名到标签列表的映射
+func (c *Client) WatchServices(fn func(map[string][]string)) (*WatchHandle, error) {
+	if fn == nil {
+		return nil, fmt.Errorf("watch handler cannot be nil")
+	}
+	return c.runPlan(map[string]interface{}{"type": "services"}, func(raw interface{}) {
+		services, _ := raw.(map[string][]string)
+		fn(services)
+	})
+}
+
+// WatchService 监听指定服务的实例列表
+func (c *Client) WatchService(name string, passingOnly bool, fn func([]*api.ServiceEntry)) (*WatchHandle, error) {
+	if fn == nil {
+		return nil, fmt.Errorf("watch handler cannot be nil")
+	}
+	if name == "" {
+		return nil, fmt.Errorf("service name cannot be empty")
+	}
+	params := map[string]interface{}{
+		"type":        "service",
+		"service":     name,
+		"passingonly": passingOnly,
+	}
+	return c.runPlan(params, func(raw interface{}) {
+		entries, _ := raw.([]*api.ServiceEntry)
+		fn(entries)
+	})
+}
+
+// WatchNodes 监听节点列表
+func (c *Client) WatchNodes(fn func([]*api.Node)) (*WatchHandle, error) {
+	if fn == nil {
+		return nil, fmt.Errorf("watch handler cannot be nil")
+	}
+	return c.runPlan(map[string]interface{}{"type": "nodes"}, func(raw interface{}) {
+		nodes, _ := raw.([]*api.Node)
+		fn(nodes)
+	})
+}
+
+// WatchChecks 监听健康检查，service为空时监听所有检查
+func (c *Client) WatchChecks(service string, fn func([]*api.HealthCheck)) (*WatchHandle, error) {
+	if fn == nil {
+		return nil, fmt.Errorf("watch handler cannot be nil")
+	}
+	params := map[string]interface{}{"type": "checks"}
+	if service != "" {
+		params["service"] = service
+	}
+	return c.runPlan(params, func(raw interface{}) {
+		checks, _ := raw.([]*api.HealthCheck)
+		fn(checks)
+	})
+}
+
+// WatchEvent 监听用户事件，name为空时监听所有事件
+func (c *Client) WatchEvent(name string, fn func([]*api.UserEvent)) (*WatchHandle, error) {
+	if fn == nil {
+		return nil, fmt.Errorf("watch handler cannot be nil")
+	}
+	params := map[string]interface{}{"type": "event"}
+	if name != "" {
+		params["name"] = name
+	}
+	return c.runPlan(params, func(raw interface{}) {
+		events, _ := raw.([]*api.UserEvent)
+		fn(events)
+	})
+}
+
+// runPlan 创建并在后台运行watch plan，客户端关闭时自动停止
+func (c *Client) runPlan(params map[string]interface{}, handler func(interface{})) (*WatchHandle, error) {
+	if c.config.datacenter != "" {
+		params["datacenter"] = c.config.datacenter
+	}
+	if c.config.token != "" {
+		params["token"] = c.config.token
+	}
+
+	name := fmt.Sprint("watch plan ", params["type"])
+	h := &WatchHandle{c: c, params: maps.Clone(params), handler: handler, done: make(chan struct{})}
+	plan, err := h.newPlan()
+	if err != nil {
+		return nil, err
+	}
+	h.plan = plan
+
+	c.goWorker(name, func() {
+		defer close(h.done)
+		h.run()
+	})
+	go func() {
+		select {
+		case <-c.ctx.Done():
+			h.Stop()
+		case <-h.done:
+		}
+	}()
+
+	return h, nil
+}
@@ -0,0 +1,166 @@
+// watch_set.go
+package consul
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// ConfigSetHandler 配置集合变更回调，changed为本次合并的变更键（已排序）
+type ConfigSetHandler func(changed []string)
+
+// configUpdate 单个键的变更通知
+type configUpdate struct {
+	key  string
+	pair *api.KVPair
+}
+
+// WatchConfigSet 同时监听多个配置键，targets为键到目标结构体的映射。
+// 任一键变化后静默Debounce时间，再将变更解析到对应结构体并合并触发一次onChange，
+// 适用于由公共配置与服务私有配置组合而成的场景。opts为nil时Debounce默认500ms
+func (c *Client) WatchConfigSet(targets map[string]interface{}, onChange ConfigSetHandler, opts *WatchOptions) error {
+	if len(targets) == 0 {
+		return fmt.Errorf("targets cannot be empty")
+	}
+	for key := range targets {
+		if key == "" {
+			return fmt.Errorf("key cannot be empty")
+		}
+	}
+
+	if opts == nil {
+		opts = defaultWatchOptions()
+		opts.Debounce = 500 * time.Millisecond
+	}
+	if opts.JSONSchema != nil {
+		if _, err := CompileSchema(opts.JSONSchema); err != nil {
+			return err
+		}
+	}
+
+	// 先获取全部初始配置
+	keys := slices.Sorted(maps.Keys(targets))
+	defaults, _ := opts.Defaults.(map[string]interface{})
+	for _, key := range keys {
+		if err := c.loadInitial(c.ctx, key, targets[key], defaults[key], opts); err != nil {
+			return err
+		}
+	}
+
+	c.startConfigWatches(targets, defaults, onChange, opts)
+
+	return nil
+}
+
+// startConfigWatches 为每个键启动阻塞查询，变更统一交给合并协程处理
+func (c *Client) startConfigWatches(targets, defaults map[string]interface{}, onChange ConfigSetHandler, opts *WatchOptions) {
+	updates := make(chan configUpdate)
+	wake := make(chan struct{}, 1)
+	ctx, stop := context.WithCancel(c.ctx)
+	states := make(map[string]*watchState, len(targets))
+	for key := range targets {
+		state := c.trackWatch(key)
+		state.wake = wake
+		state.ctx, state.stop = ctx, stop
+		states[key] = state
+		c.goWorker("watch "+key, func() {
+			defer c.untrackWatch(state)
+			c.watchLoop(state, opts, func(pair *api.KVPair) {
+				select {
+				case updates <- configUpdate{key: key, pair: pair}:
+				case <-ctx.Done():
+				}
+			})
+		})
+	}
+
+	c.goWorker("config set", func() {
+		defer stop()
+		c.coalesceConfigs(ctx, targets, defaults, states, updates, wake, onChange, opts)
+	})
+}
+
+// coalesceConfigs 在单个协程中按Debounce和MinInterval合并变更，
+// 到期后只解析每个键的最新值并触发一次回调，回调执行期间目标结构体不会被修改
+func (c *Client) coalesceConfigs(ctx context.Context, targets, defaults map[string]interface{}, states map[string]*watchState, updates <-chan configUpdate, wake <-chan struct{}, onChange ConfigSetHandler, opts *WatchOptions) {
+	pending := make(map[string]*api.KVPair)
+	var lastApply time.Time
+	var timer *time.Timer
+	var fire <-chan time.Time
+
+	apply := func() {
+		var changed []string
+		for _, key := range slices.Sorted(maps.Keys(pending)) {
+			if c.watchPaused(states[key]) {
+				continue
+			}
+			pair := pending[key]
+			delete(pending, key)
+			if pair == nil {
+				c.removeCache(key)
+				if opts.OnDelete != nil {
+					opts.OnDelete(key)
+				}
+				reset, err := resetConfig(targets[key], defaults[key], opts.DeletePolicy)
+				if err != nil {
+					c.logger.Printf("Error resetting config for %s: %v", key, err)
+				}
+				if reset {
+					changed = append(changed, key)
+				}
+				continue
+			}
+			if err := c.decodeConfig(pair, targets[key], opts); err != nil {
+				c.logger.Printf("Error parsing config for %s: %v", key, err)
+				c.reportWatch(states[key], opts, err)
+				continue
+			}
+			c.saveCache(key, pair.Value)
+			c.logger.Printf("Config updated: %s", key)
+			changed = append(changed, key)
+		}
+		lastApply = time.Now()
+		if onChange != nil && len(changed) > 0 {
+			onChange(changed)
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		case u := <-updates:
+			pending[u.key] = u.pair
+			delay := opts.Debounce
+			if wait := time.Until(lastApply.Add(opts.MinInterval)); wait > delay {
+				delay = wait
+			}
+			if delay <= 0 {
+				apply()
+				continue
+			}
+			if timer == nil {
+				timer = time.NewTimer(delay)
+			} else {
+				timer.Reset(delay)
+			}
+			fire = timer.C
+		case <-fire:
+			fire = nil
+			apply()
+		case <-wake:
+			// 有等待中的定时器时由定时器应用，避免绕过Debounce和MinInterval
+			if len(pending) > 0 && fire == nil {
+				apply()
+			}
+		}
+	}
+}
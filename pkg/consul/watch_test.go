@@ -0,0 +1,188 @@
+package consul_test
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stones-hub/taurus-pro-consul/pkg/consul"
+	"github.com/stones-hub/taurus-pro-consul/pkg/consul/consultest"
+)
+
+type watchedConfig struct {
+	Value int `json:"value"`
+}
+
+// indexProxy 转发到测试服务器，按请求的index参数改写KV查询返回的X-Consul-Index，
+// 并记录每次KV查询的index参数，用于模拟索引回退和索引为0的情况
+type indexProxy struct {
+	mu       sync.Mutex
+	requests []uint64
+	rewrite  func(requested uint64) (uint64, bool)
+}
+
+func newIndexProxy(t *testing.T, srv *consultest.TestServer, rewrite func(requested uint64) (uint64, bool)) (*indexProxy, string) {
+	t.Helper()
+	target, err := url.Parse("http://" + srv.Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &indexProxy{rewrite: rewrite}
+	rp := httputil.NewSingleHostReverseProxy(target)
+	rp.ErrorLog = log.New(io.Discard, "", 0)
+	rp.ModifyResponse = func(resp *http.Response) error {
+		if resp.Request.URL.Path != "/v1/kv/app/cfg" {
+			return nil
+		}
+		requested, _ := strconv.ParseUint(resp.Request.URL.Query().Get("index"), 10, 64)
+		p.mu.Lock()
+		p.requests = append(p.requests, requested)
+		p.mu.Unlock()
+		if index, ok := p.rewrite(requested); ok {
+			resp.Header.Set("X-Consul-Index", strconv.FormatUint(index, 10))
+		}
+		return nil
+	}
+	front := httptest.NewServer(rp)
+	t.Cleanup(front.Close)
+	return p, front.Listener.Addr().String()
+}
+
+// waitFor 等待请求序列中出现after之后紧跟next的查询
+func (p *indexProxy) waitFor(t *testing.T, after, next uint64) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		p.mu.Lock()
+		for i := 1; i < len(p.requests); i++ {
+			if p.requests[i-1] == after && p.requests[i] == next {
+				p.mu.Unlock()
+				return
+			}
+		}
+		p.mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	t.Fatalf("no query with index %d after index %d, got %v", next, after, p.requests)
+}
+
+func newProxiedClient(t *testing.T, addr string) *consul.Client {
+	t.Helper()
+	c, err := consul.NewClient(consul.WithAddress(addr), consul.WithLogger(log.New(io.Discard, "", 0)))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestWatchConfigIndexBackwardsResets(t *testing.T) {
+	srv := consultest.StartTestServer(t)
+	srv.SetKV("app/cfg", []byte(`{"value":1}`))
+
+	// 首次查询返回100，以100阻塞时返回5，模拟快照恢复后索引回退
+	proxy, addr := newIndexProxy(t, srv, func(requested uint64) (uint64, bool) {
+		switch requested {
+		case 0:
+			return 100, true
+		case 100:
+			return 5, true
+		}
+		return 0, false
+	})
+	c := newProxiedClient(t, addr)
+
+	var cfg watchedConfig
+	opts := &consul.WatchOptions{WaitTime: 50 * time.Millisecond, RetryTime: 10 * time.Millisecond}
+	if err := c.WatchConfig("app/cfg", &cfg, opts); err != nil {
+		t.Fatalf("WatchConfig: %v", err)
+	}
+
+	// 回退后应以索引0重新读取，而不是以5阻塞
+	proxy.waitFor(t, 100, 0)
+	proxy.mu.Lock()
+	defer proxy.mu.Unlock()
+	for _, index := range proxy.requests {
+		if index == 5 {
+			t.Fatalf("watch blocked on backwards index 5: %v", proxy.requests)
+		}
+	}
+}
+
+func TestWatchConfigZeroIndexTreatedAsOne(t *testing.T) {
+	srv := consultest.StartTestServer(t)
+	srv.SetKV("app/cfg", []byte(`{"value":1}`))
+
+	proxy, addr := newIndexProxy(t, srv, func(requested uint64) (uint64, bool) {
+		return 0, true
+	})
+	c := newProxiedClient(t, addr)
+
+	var cfg watchedConfig
+	opts := &consul.WatchOptions{WaitTime: 50 * time.Millisecond, RetryTime: 10 * time.Millisecond}
+	if err := c.WatchConfig("app/cfg", &cfg, opts); err != nil {
+		t.Fatalf("WatchConfig: %v", err)
+	}
+
+	// 返回索引0后应以1阻塞，避免以0反复查询造成忙等
+	proxy.waitFor(t, 0, 1)
+}
+
+func TestWatchConfigDeleteFiresOnDeleteAndPolicy(t *testing.T) {
+	c, srv := consultest.NewFakeClient(t)
+	srv.SetKV("app/cfg", []byte(`{"value":7}`))
+
+	deleted := make(chan string, 1)
+	changed := make(chan watchedConfig, 1)
+	cfg := watchedConfig{}
+	opts := &consul.WatchOptions{
+		WaitTime:     time.Second,
+		RetryTime:    10 * time.Millisecond,
+		DeletePolicy: consul.DeleteDefaults,
+		Defaults:     map[string]interface{}{"app/cfg": watchedConfig{Value: 42}},
+		OnDelete:     func(key string) { deleted <- key },
+	}
+	err := c.WatchConfigSet(map[string]interface{}{"app/cfg": &cfg}, func([]string) {
+		changed <- cfg
+	}, opts)
+	if err != nil {
+		t.Fatalf("WatchConfigSet: %v", err)
+	}
+	// 等待监听首次查询到键后再删除
+	select {
+	case got := <-changed:
+		if got.Value != 7 {
+			t.Fatalf("initial value = %d, want 7", got.Value)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch did not start")
+	}
+
+	srv.DeleteKV("app/cfg")
+
+	select {
+	case key := <-deleted:
+		if key != "app/cfg" {
+			t.Fatalf("OnDelete key = %q, want app/cfg", key)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnDelete was not called")
+	}
+	select {
+	case got := <-changed:
+		if got.Value != 42 {
+			t.Fatalf("value after delete = %d, want defaults 42", got.Value)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("config was not reset after delete")
+	}
+}